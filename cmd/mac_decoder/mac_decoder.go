@@ -0,0 +1,74 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// mac_decoder prints the xname and BMC type encoded in MAC addresses
+// generated by MEDS, e.g. from DHCP logs.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Cray-HPE/hms-meds/internal/macaddr"
+)
+
+func main() {
+	var prefix, schemeName string
+	flag.StringVar(&prefix, "prefix", macaddr.DefaultPrefix,
+		"MAC address prefix of the cabinet (the MACPrefix of its HMN network in SLS)")
+	flag.StringVar(&schemeName, "scheme", macaddr.DefaultSchemeName,
+		"MAC address scheme of the cabinet, one of: "+strings.Join(macaddr.MACSchemeNames(), ", "))
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-prefix PREFIX] [-scheme SCHEME] MAC...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	scheme, ok := macaddr.GetMACScheme(schemeName)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown MAC scheme '%s'\n", schemeName)
+		os.Exit(2)
+	}
+
+	failed := false
+	for _, mac := range flag.Args() {
+		xname, epType, err := scheme.Parse(prefix, mac)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", mac, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("%s %s %s\n", mac, xname, epType)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,487 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-xname/xnametypes"
+)
+
+// EndpointStatus is MEDS' view of a single tracked endpoint, as returned
+// by the status API.
+type EndpointStatus struct {
+	Xname              string              `json:"Xname"`
+	Type               string              `json:"Type"`
+	MACAddr            string              `json:"MACAddr,omitempty"`
+	HSMPresence        string              `json:"HSMPresence"`
+	LastPingTime       *time.Time          `json:"LastPingTime,omitempty"`
+	LastPingResult     string              `json:"LastPingResult,omitempty"`
+	LastPingError      string              `json:"LastPingError,omitempty"`
+	ServiceRoot        *ServiceRootInfo    `json:"ServiceRoot,omitempty"`
+	Compliance         *EndpointCompliance `json:"Compliance,omitempty"`
+	Credentials        *CredentialCheck    `json:"Credentials,omitempty"`
+	Initialization     *InitStatus         `json:"Initialization,omitempty"`
+	LastTransitionTime *time.Time          `json:"LastTransitionTime,omitempty"`
+	MissingSince       *time.Time          `json:"MissingSince,omitempty"`
+}
+
+type EndpointStatusArray struct {
+	Endpoints []EndpointStatus `json:"Endpoints"`
+}
+
+// ChassisStatus groups the endpoints MEDS is tracking for one chassis.
+type ChassisStatus struct {
+	Xname     string           `json:"Xname"`
+	Endpoints []EndpointStatus `json:"Endpoints"`
+}
+
+type ChassisStatusArray struct {
+	Chassis []ChassisStatus `json:"Chassis"`
+}
+
+// RediscoverResult is the outcome of an on-demand rediscovery of a single
+// endpoint.
+type RediscoverResult struct {
+	Xname   string `json:"Xname"`
+	Success bool   `json:"Success"`
+	Address string `json:"Address,omitempty"`
+	Error   string `json:"Error,omitempty"`
+}
+
+type RediscoverResultArray struct {
+	Results []RediscoverResult `json:"Results"`
+}
+
+var httpServer *http.Server
+
+// These are swapped out by the unit tests.
+var rediscoverNetQuery = queryNetworkStatus
+var rediscoverOnPresent = notifyXnamePresent
+
+// getEndpointStatus takes a consistent snapshot of an endpoint's state.
+func getEndpointStatus(ne *NetEndpoint) EndpointStatus {
+	ne.HSMPresLock.Lock()
+	defer ne.HSMPresLock.Unlock()
+
+	status := EndpointStatus{
+		Xname:       ne.name,
+		Type:        EndpointTypeToString[ne.hwtype],
+		MACAddr:     ne.mac,
+		HSMPresence: HSMEndpointPresenceToString[ne.HSMPresence],
+	}
+	if !ne.lastPing.IsZero() {
+		lastPing := ne.lastPing
+		status.LastPingTime = &lastPing
+		status.LastPingResult = HSMEndpointPresenceToString[ne.lastPingPresence]
+		status.LastPingError = ne.lastPingErr
+	}
+	if ne.serviceRoot.Result != "" {
+		serviceRoot := ne.serviceRoot
+		status.ServiceRoot = &serviceRoot
+	}
+	if !ne.compliance.CheckedAt.IsZero() {
+		compliance := ne.compliance
+		status.Compliance = &compliance
+	}
+	if !ne.credentialCheck.CheckedAt.IsZero() {
+		credentialCheck := ne.credentialCheck
+		status.Credentials = &credentialCheck
+	}
+	if !ne.initStatus.StartedAt.IsZero() {
+		initStatus := ne.initStatus
+		status.Initialization = &initStatus
+	}
+	if !ne.lastTransition.IsZero() {
+		lastTransition := ne.lastTransition
+		status.LastTransitionTime = &lastTransition
+	}
+	if !ne.missingSince.IsZero() {
+		missingSince := ne.missingSince
+		status.MissingSince = &missingSince
+	}
+	return status
+}
+
+func getEndpointStatusList(endpoints []*NetEndpoint) []EndpointStatus {
+	statusList := make([]EndpointStatus, 0, len(endpoints))
+	for _, ne := range endpoints {
+		statusList = append(statusList, getEndpointStatus(ne))
+	}
+	sort.Slice(statusList, func(i, j int) bool {
+		return statusList[i].Xname < statusList[j].Xname
+	})
+	return statusList
+}
+
+func sendJSONResponse(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(payload)
+	if err != nil {
+		log.Printf("WARNING: Unable to encode JSON response: %v", err)
+	}
+}
+
+// getActiveEndpoints lists every tracked endpoint.
+func getActiveEndpoints() []*NetEndpoint {
+	activeEndpointsLock.Lock()
+	defer activeEndpointsLock.Unlock()
+	endpoints := make([]*NetEndpoint, 0, len(activeEndpoints))
+	for _, ne := range activeEndpoints {
+		endpoints = append(endpoints, ne)
+	}
+	return endpoints
+}
+
+// GET /v1/endpoints
+func doEndpointsGet(w http.ResponseWriter, r *http.Request) {
+	sendJSONResponse(w, http.StatusOK,
+		EndpointStatusArray{Endpoints: getEndpointStatusList(getActiveEndpoints())})
+}
+
+// GET /v1/endpoints/{xname}
+func doEndpointGet(w http.ResponseWriter, r *http.Request) {
+	xname := xnametypes.NormalizeHMSCompID(r.PathValue("xname"))
+
+	activeEndpointsLock.Lock()
+	ne, ok := activeEndpoints[xname]
+	activeEndpointsLock.Unlock()
+
+	if !ok {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			"MEDS is not tracking endpoint "+xname)
+		return
+	}
+	sendJSONResponse(w, http.StatusOK, getEndpointStatus(ne))
+}
+
+// GET /v1/chassis
+func doChassisListGet(w http.ResponseWriter, r *http.Request) {
+	activeEndpointsLock.Lock()
+	chassisEndpoints := make(map[string][]*NetEndpoint, len(activeChassis))
+	for chassis, endpoints := range activeChassis {
+		chassisEndpoints[chassis] = append([]*NetEndpoint{}, endpoints...)
+	}
+	activeEndpointsLock.Unlock()
+
+	chassisList := make([]ChassisStatus, 0, len(chassisEndpoints))
+	for chassis, endpoints := range chassisEndpoints {
+		chassisList = append(chassisList, ChassisStatus{
+			Xname:     chassis,
+			Endpoints: getEndpointStatusList(endpoints),
+		})
+	}
+	sort.Slice(chassisList, func(i, j int) bool {
+		return chassisList[i].Xname < chassisList[j].Xname
+	})
+
+	sendJSONResponse(w, http.StatusOK, ChassisStatusArray{Chassis: chassisList})
+}
+
+// GET /v1/chassis/{xname}
+func doChassisGet(w http.ResponseWriter, r *http.Request) {
+	xname := xnametypes.NormalizeHMSCompID(r.PathValue("xname"))
+
+	activeEndpointsLock.Lock()
+	endpoints, ok := activeChassis[xname]
+	endpoints = append([]*NetEndpoint{}, endpoints...)
+	activeEndpointsLock.Unlock()
+
+	if !ok {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			"MEDS is not tracking chassis "+xname)
+		return
+	}
+	sendJSONResponse(w, http.StatusOK, ChassisStatus{
+		Xname:     xname,
+		Endpoints: getEndpointStatusList(endpoints),
+	})
+}
+
+// GET /v1/compliance
+//
+// Only BMCs that have been checked are listed; ?drifted=true limits the
+// list to those that weren't compliant.
+func doComplianceGet(w http.ResponseWriter, r *http.Request) {
+	driftedOnly := r.URL.Query().Get("drifted") == "true"
+
+	results := make([]EndpointCompliance, 0)
+	for _, ne := range getActiveEndpoints() {
+		ne.HSMPresLock.Lock()
+		result := ne.compliance
+		ne.HSMPresLock.Unlock()
+		if !result.CheckedAt.IsZero() && (!driftedOnly || !result.Compliant) {
+			results = append(results, result)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Xname < results[j].Xname
+	})
+	sendJSONResponse(w, http.StatusOK, EndpointComplianceArray{Endpoints: results})
+}
+
+// GET /v1/credentials
+//
+// Only BMCs that have been checked are listed; ?failed=true limits the list
+// to those whose Vault credentials didn't work.
+func doCredentialChecksGet(w http.ResponseWriter, r *http.Request) {
+	failedOnly := r.URL.Query().Get("failed") == "true"
+
+	checks := make([]CredentialCheck, 0)
+	for _, ne := range getActiveEndpoints() {
+		ne.HSMPresLock.Lock()
+		check := ne.credentialCheck
+		ne.HSMPresLock.Unlock()
+		if !check.CheckedAt.IsZero() && (!failedOnly || check.Result == CREDENTIALS_AUTH_FAILED) {
+			checks = append(checks, check)
+		}
+	}
+
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].Xname < checks[j].Xname
+	})
+	sendJSONResponse(w, http.StatusOK, CredentialCheckArray{Endpoints: checks})
+}
+
+// POST /v1/endpoints/{xname}/rediscover
+func doEndpointRediscoverPost(w http.ResponseWriter, r *http.Request) {
+	xname := xnametypes.NormalizeHMSCompID(r.PathValue("xname"))
+
+	activeEndpointsLock.Lock()
+	ne, ok := activeEndpoints[xname]
+	activeEndpointsLock.Unlock()
+
+	if !ok {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			"MEDS is not tracking endpoint "+xname)
+		return
+	}
+
+	result := rediscoverEndpoint(r.Context(), ne, rediscoverNetQuery, rediscoverOnPresent)
+	if result.Success {
+		sendJSONResponse(w, http.StatusOK, result)
+	} else {
+		sendJSONResponse(w, http.StatusBadGateway, result)
+	}
+}
+
+// POST /v1/chassis/{xname}/rediscover
+//
+// Every endpoint in the chassis is rediscovered in parallel; the response
+// contains the individual results.
+func doChassisRediscoverPost(w http.ResponseWriter, r *http.Request) {
+	xname := xnametypes.NormalizeHMSCompID(r.PathValue("xname"))
+
+	activeEndpointsLock.Lock()
+	endpoints, ok := activeChassis[xname]
+	endpoints = append([]*NetEndpoint{}, endpoints...)
+	activeEndpointsLock.Unlock()
+
+	if !ok {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			"MEDS is not tracking chassis "+xname)
+		return
+	}
+
+	results := make([]RediscoverResult, len(endpoints))
+	var wg sync.WaitGroup
+	for i, ne := range endpoints {
+		wg.Add(1)
+		go func(i int, ne *NetEndpoint) {
+			defer wg.Done()
+			results[i] = rediscoverEndpoint(r.Context(), ne, rediscoverNetQuery, rediscoverOnPresent)
+		}(i, ne)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Xname < results[j].Xname
+	})
+	succeeded := 0
+	for _, result := range results {
+		if result.Success {
+			succeeded++
+		}
+	}
+	sendJSONResponse(w, chassisStatusCode(len(results), succeeded), RediscoverResultArray{Results: results})
+}
+
+// chassisStatusCode is the status of an operation on every endpoint of a
+// chassis: 200 if it succeeded for all of them, 207 if only for some, and
+// 502 if for none.
+func chassisStatusCode(total, succeeded int) int {
+	switch {
+	case succeeded == total && total > 0:
+		return http.StatusOK
+	case succeeded > 0:
+		return http.StatusMultiStatus
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// POST /v1/endpoints/{xname}/rotate-credentials
+func doEndpointRotatePost(w http.ResponseWriter, r *http.Request) {
+	xname := xnametypes.NormalizeHMSCompID(r.PathValue("xname"))
+
+	activeEndpointsLock.Lock()
+	ne, ok := activeEndpoints[xname]
+	activeEndpointsLock.Unlock()
+
+	if !ok {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			"MEDS is not tracking endpoint "+xname)
+		return
+	}
+
+	// Don't leave a BMC with a changed password that isn't in Vault if the
+	// client goes away.
+	result := rotateEndpoint(context.WithoutCancel(r.Context()), ne)
+	if result.Success {
+		sendJSONResponse(w, http.StatusOK, result)
+	} else {
+		sendJSONResponse(w, http.StatusBadGateway, result)
+	}
+}
+
+// POST /v1/chassis/{xname}/rotate-credentials
+//
+// The credentials of every BMC in the chassis are rotated in parallel; the
+// response contains the individual results.
+func doChassisRotatePost(w http.ResponseWriter, r *http.Request) {
+	xname := xnametypes.NormalizeHMSCompID(r.PathValue("xname"))
+
+	activeEndpointsLock.Lock()
+	endpoints, ok := activeChassis[xname]
+	endpoints = append([]*NetEndpoint{}, endpoints...)
+	activeEndpointsLock.Unlock()
+
+	if !ok {
+		base.SendProblemDetailsGeneric(w, http.StatusNotFound,
+			"MEDS is not tracking chassis "+xname)
+		return
+	}
+
+	// Don't leave BMCs with changed passwords that aren't in Vault if the
+	// client goes away.
+	ctx := context.WithoutCancel(r.Context())
+	results := make([]RotationResult, len(endpoints))
+	var wg sync.WaitGroup
+	for i, ne := range endpoints {
+		wg.Add(1)
+		go func(i int, ne *NetEndpoint) {
+			defer wg.Done()
+			results[i] = rotateEndpoint(ctx, ne)
+		}(i, ne)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Xname < results[j].Xname
+	})
+	succeeded := 0
+	for _, result := range results {
+		if result.Success {
+			succeeded++
+		}
+	}
+	sendJSONResponse(w, chassisStatusCode(len(results), succeeded), RotationResultArray{Results: results})
+}
+
+// refuseWhenShuttingDown turns away requests that arrive once MEDS has
+// started shutting down, so that no new work is started while it drains.
+func refuseWhenShuttingDown(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if medsHealth.isShuttingDown() {
+			base.SendProblemDetailsGeneric(w, http.StatusServiceUnavailable, "MEDS is shutting down")
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func newRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", doLivenessGet)
+	mux.HandleFunc("GET /readyz", doReadinessGet)
+	mux.HandleFunc("GET /metrics", doMetricsGet)
+
+	api := func(pattern string, handler http.HandlerFunc) {
+		mux.HandleFunc(pattern, refuseWhenShuttingDown(handler))
+	}
+	api("GET /v1/endpoints", doEndpointsGet)
+	api("GET /v1/endpoints/{xname}", doEndpointGet)
+	api("GET /v1/chassis", doChassisListGet)
+	api("GET /v1/chassis/{xname}", doChassisGet)
+	api("GET /v1/compliance", doComplianceGet)
+	api("GET /v1/credentials", doCredentialChecksGet)
+	api("POST /v1/endpoints/{xname}/rediscover", doEndpointRediscoverPost)
+	api("POST /v1/chassis/{xname}/rediscover", doChassisRediscoverPost)
+	if credentialRotation {
+		api("POST /v1/endpoints/{xname}/rotate-credentials", doEndpointRotatePost)
+		api("POST /v1/chassis/{xname}/rotate-credentials", doChassisRotatePost)
+	}
+	api("POST /v1/scn", doSCNPost)
+	return mux
+}
+
+// startHTTPServer starts the MEDS status API in the background.
+func startHTTPServer() {
+	httpServer = &http.Server{
+		Addr:    httpListen,
+		Handler: newRouter(),
+	}
+
+	log.Printf("INFO: Starting MEDS HTTP server on '%s'", httpListen)
+	go func() {
+		err := httpServer.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			log.Printf("ERROR: MEDS HTTP server failed: %v", err)
+		}
+		log.Printf("INFO: MEDS HTTP server stopped")
+	}()
+}
+
+// stopHTTPServer stops the MEDS status API, waiting until ctx is done for
+// requests in progress to finish.
+func stopHTTPServer(ctx context.Context) {
+	if httpServer == nil {
+		return
+	}
+	err := httpServer.Shutdown(ctx)
+	if err != nil {
+		log.Printf("WARNING: MEDS HTTP server did not shut down cleanly: %v", err)
+		httpServer.Close()
+	}
+}
@@ -0,0 +1,336 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func setupAPITestEndpoints() {
+	cc := &NetEndpoint{
+		name:        "x1000c3b0",
+		mac:         "02:03:E8:03:00:00",
+		hwtype:      TYPE_CHASSIS,
+		HSMPresence: PRESENCE_PRESENT,
+	}
+	nc := &NetEndpoint{
+		name:             "x1000c3s5b1",
+		mac:              "02:03:E8:03:35:10",
+		hwtype:           TYPE_NODE_CARD,
+		HSMPresence:      PRESENCE_NOT_PRESENT,
+		lastPing:         time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		lastPingPresence: PRESENCE_NOT_PRESENT,
+		lastPingErr:      "Not found. Tried x1000c3s5b1: dummy",
+	}
+
+	activeEndpoints = map[string]*NetEndpoint{cc.name: cc, nc.name: nc}
+	activeChassis = map[string][]*NetEndpoint{"x1000c3": {nc, cc}}
+}
+
+func Test_doEndpointsGet(t *testing.T) {
+	setupAPITestEndpoints()
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/endpoints", nil)
+	w := httptest.NewRecorder()
+	newRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status code: %d", w.Code)
+	}
+
+	var rsp EndpointStatusArray
+	if err := json.Unmarshal(w.Body.Bytes(), &rsp); err != nil {
+		t.Fatalf("Unable to unmarshal response: %v", err)
+	}
+	if len(rsp.Endpoints) != 2 {
+		t.Fatalf("Expected 2 endpoints, got %d", len(rsp.Endpoints))
+	}
+	if rsp.Endpoints[0].Xname != "x1000c3b0" || rsp.Endpoints[1].Xname != "x1000c3s5b1" {
+		t.Errorf("Endpoints not sorted by xname: %v", rsp.Endpoints)
+	}
+	if rsp.Endpoints[0].HSMPresence != "present" || rsp.Endpoints[0].LastPingTime != nil {
+		t.Errorf("Unexpected status for never-pinged endpoint: %+v", rsp.Endpoints[0])
+	}
+}
+
+func Test_doEndpointGet(t *testing.T) {
+	setupAPITestEndpoints()
+
+	tests := []struct {
+		description  string
+		uri          string
+		expectedCode int
+	}{{
+		"Tracked endpoint",
+		"/v1/endpoints/x1000c3s5b1",
+		http.StatusOK,
+	}, {
+		"Tracked endpoint, non-normalized xname",
+		"/v1/endpoints/X1000c03s05b1",
+		http.StatusOK,
+	}, {
+		"Untracked endpoint",
+		"/v1/endpoints/x1000c3s6b1",
+		http.StatusNotFound,
+	}}
+
+	for i, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.uri, nil)
+		w := httptest.NewRecorder()
+		newRouter().ServeHTTP(w, req)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("Test %v (%s) Failed: Expected status code %d; Received %d", i, test.description, test.expectedCode, w.Code)
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+
+		var rsp EndpointStatus
+		if err := json.Unmarshal(w.Body.Bytes(), &rsp); err != nil {
+			t.Errorf("Test %v (%s) Failed: Unable to unmarshal response: %v", i, test.description, err)
+			continue
+		}
+		if rsp.Xname != "x1000c3s5b1" || rsp.Type != "Node Card" || rsp.HSMPresence != "not present" ||
+			rsp.LastPingResult != "not present" || rsp.LastPingError == "" || rsp.LastPingTime == nil {
+			t.Errorf("Test %v (%s) Failed: Unexpected endpoint status %+v", i, test.description, rsp)
+		}
+	}
+}
+
+func Test_doChassisGet(t *testing.T) {
+	setupAPITestEndpoints()
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/chassis", nil)
+	w := httptest.NewRecorder()
+	newRouter().ServeHTTP(w, req)
+
+	var rspList ChassisStatusArray
+	if err := json.Unmarshal(w.Body.Bytes(), &rspList); err != nil {
+		t.Fatalf("Unable to unmarshal response: %v", err)
+	}
+	if len(rspList.Chassis) != 1 || len(rspList.Chassis[0].Endpoints) != 2 {
+		t.Errorf("Unexpected chassis list: %+v", rspList)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/chassis/x1000c4", nil)
+	w = httptest.NewRecorder()
+	newRouter().ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for untracked chassis, got %d", w.Code)
+	}
+}
+
+func Test_doEndpointRediscoverPost(t *testing.T) {
+	defer func() {
+		rediscoverNetQuery = queryNetworkStatus
+		rediscoverOnPresent = notifyXnamePresent
+	}()
+
+	addr := "x1000c3s5b1"
+	presentErr := errors.New("dummy")
+
+	tests := []struct {
+		description     string
+		uri             string
+		netPresence     HSMEndpointPresence
+		netErr          *error
+		presentErr      *error
+		expectedCode    int
+		expectPresent   bool
+		expectPresCalls int
+	}{{
+		"Present endpoint is re-initialized even though HSM has it as present",
+		"/v1/endpoints/x1000c3b0/rediscover",
+		PRESENCE_PRESENT,
+		nil,
+		nil,
+		http.StatusOK,
+		true,
+		1,
+	}, {
+		"Not-present endpoint answers",
+		"/v1/endpoints/x1000c3s5b1/rediscover",
+		PRESENCE_PRESENT,
+		nil,
+		nil,
+		http.StatusOK,
+		true,
+		1,
+	}, {
+		"Endpoint does not answer",
+		"/v1/endpoints/x1000c3s5b1/rediscover",
+		PRESENCE_NOT_PRESENT,
+		&presentErr,
+		nil,
+		http.StatusBadGateway,
+		false,
+		0,
+	}, {
+		"Initialization fails",
+		"/v1/endpoints/x1000c3s5b1/rediscover",
+		PRESENCE_PRESENT,
+		nil,
+		&presentErr,
+		http.StatusBadGateway,
+		false,
+		1,
+	}, {
+		"Untracked endpoint",
+		"/v1/endpoints/x1000c3s6b1/rediscover",
+		PRESENCE_PRESENT,
+		nil,
+		nil,
+		http.StatusNotFound,
+		false,
+		0,
+	}}
+
+	for i, test := range tests {
+		setupAPITestEndpoints()
+		configure_queryNet(test.netPresence, &addr, test.netErr)
+		configure_notifyHSMPresent(test.presentErr)
+		rediscoverNetQuery = mock_queryNet
+		rediscoverOnPresent = mock_notifyHSMPresent
+
+		req := httptest.NewRequest(http.MethodPost, test.uri, nil)
+		w := httptest.NewRecorder()
+		newRouter().ServeHTTP(w, req)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("Test %v (%s) Failed: Expected status code %d; Received %d", i, test.description, test.expectedCode, w.Code)
+		}
+		if len(notifyHSMPresentCalls) != test.expectPresCalls {
+			t.Errorf("Test %v (%s) Failed: Expected %d notifyHSMPresent calls; Received %d", i, test.description, test.expectPresCalls, len(notifyHSMPresentCalls))
+		}
+		if w.Code == http.StatusNotFound {
+			continue
+		}
+
+		var rsp RediscoverResult
+		if err := json.Unmarshal(w.Body.Bytes(), &rsp); err != nil {
+			t.Errorf("Test %v (%s) Failed: Unable to unmarshal response: %v", i, test.description, err)
+			continue
+		}
+		if rsp.Success != test.expectPresent {
+			t.Errorf("Test %v (%s) Failed: Unexpected result %+v", i, test.description, rsp)
+		}
+		ne := activeEndpoints[rsp.Xname]
+		if test.expectPresent && ne.HSMPresence != PRESENCE_PRESENT {
+			t.Errorf("Test %v (%s) Failed: Endpoint not marked present", i, test.description)
+		}
+		if ne.lastPing.IsZero() {
+			t.Errorf("Test %v (%s) Failed: Ping time not recorded", i, test.description)
+		}
+	}
+}
+
+func Test_doChassisRediscoverPost(t *testing.T) {
+	defer func() {
+		rediscoverNetQuery = queryNetworkStatus
+		rediscoverOnPresent = notifyXnamePresent
+	}()
+
+	setupAPITestEndpoints()
+
+	// The chassis endpoints are rediscovered in parallel, so the mocks
+	// need to be safe for concurrent use.
+	var lock sync.Mutex
+	var initialized []string
+	unreachable := map[int]bool{TYPE_NODE_CARD: true}
+	rediscoverNetQuery = func(ctx context.Context, ne *NetEndpoint) (HSMEndpointPresence, *string, *error) {
+		if unreachable[ne.hwtype] {
+			err := errors.New("dummy")
+			return PRESENCE_NOT_PRESENT, nil, &err
+		}
+		addr := ne.name
+		return PRESENCE_PRESENT, &addr, nil
+	}
+	rediscoverOnPresent = func(ctx context.Context, ne *NetEndpoint, addr string) *error {
+		lock.Lock()
+		defer lock.Unlock()
+		initialized = append(initialized, ne.name)
+		return nil
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chassis/x1000c3/rediscover", nil)
+	w := httptest.NewRecorder()
+	newRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("Unexpected status code: %d", w.Code)
+	}
+	var rsp RediscoverResultArray
+	if err := json.Unmarshal(w.Body.Bytes(), &rsp); err != nil {
+		t.Fatalf("Unable to unmarshal response: %v", err)
+	}
+	if len(rsp.Results) != 2 ||
+		rsp.Results[0].Xname != "x1000c3b0" || !rsp.Results[0].Success ||
+		rsp.Results[1].Xname != "x1000c3s5b1" || rsp.Results[1].Success || rsp.Results[1].Error == "" {
+		t.Errorf("Unexpected results: %+v", rsp)
+	}
+	if len(initialized) != 1 || initialized[0] != "x1000c3b0" {
+		t.Errorf("Unexpected endpoints initialized: %v", initialized)
+	}
+
+	tests := []struct {
+		description string
+		unreachable map[int]bool
+		expectCode  int
+	}{{
+		"All rediscovered",
+		map[int]bool{},
+		http.StatusOK,
+	}, {
+		"None rediscovered",
+		map[int]bool{TYPE_NODE_CARD: true, TYPE_CHASSIS: true},
+		http.StatusBadGateway,
+	}}
+
+	for i, test := range tests {
+		unreachable = test.unreachable
+		req = httptest.NewRequest(http.MethodPost, "/v1/chassis/x1000c3/rediscover", nil)
+		w = httptest.NewRecorder()
+		newRouter().ServeHTTP(w, req)
+		if w.Code != test.expectCode {
+			t.Errorf("Test %v (%s) Failed: Expected status %d; Received %d", i, test.description, test.expectCode, w.Code)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/chassis/x1000c4/rediscover", nil)
+	w = httptest.NewRecorder()
+	newRouter().ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for untracked chassis, got %d", w.Code)
+	}
+}
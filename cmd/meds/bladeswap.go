@@ -0,0 +1,123 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"log"
+
+	bmc_nwprotocol "github.com/Cray-HPE/hms-bmc-networkprotocol/pkg"
+)
+
+// Why MEDS reinitializes an endpoint that is already present in HSM.
+const (
+	REINIT_FINGERPRINT      = "fingerprint"      // The BMC's service root UUID changed, e.g. a blade swap
+	REINIT_FACTORY_SETTINGS = "factory_settings" // The BMC came back without the settings MEDS pushed
+)
+
+// This is swapped out by the unit tests.
+var checkFactorySettings = bmcHasFactorySettings
+
+// bmcFingerprint identifies the BMC answering for an endpoint by the UUID
+// of its Redfish service root, from the last Redfish ping.  The caller
+// must hold ne.HSMPresLock.
+func bmcFingerprint(ne *NetEndpoint) string {
+	return ne.serviceRoot.UUID
+}
+
+// bmcHasFactorySettings reads the NetworkProtocol of a BMC and says
+// whether it has lost the NTP and syslog servers MEDS pushes to it, as it
+// does when it is reset to factory settings.  It is always false if MEDS
+// pushes neither.
+func bmcHasFactorySettings(ctx context.Context, xname, address string) bool {
+	var wantNTP, wantSyslog []string
+	if rfNWPStatic.NTP != nil {
+		wantNTP = rfNWPStatic.NTP.NTPServers
+	}
+	if rfNWPStatic.Oem != nil && rfNWPStatic.Oem.Syslog != nil {
+		wantSyslog = rfNWPStatic.Oem.Syslog.SyslogServers
+	}
+	if len(wantNTP) == 0 && len(wantSyslog) == 0 {
+		return false
+	}
+
+	cred, err := hcs.GetCompCred(xname)
+	recordResult("vault", "get_comp_cred", err)
+	if err != nil || cred.Username == "" {
+		log.Printf("WARNING: Can't check %s for factory settings, no credentials: %v", xname, err)
+		return false
+	}
+
+	var np bmc_nwprotocol.RedfishNWProtocol
+	err = redfishGet(ctx, address, redfishNPSuffix, cred.Username, cred.Password, "get_network_protocol", &np)
+	if err != nil {
+		log.Printf("WARNING: Can't check %s for factory settings: %v", xname, err)
+		return false
+	}
+
+	var haveNTP, haveSyslog []string
+	if np.NTP != nil {
+		haveNTP = np.NTP.NTPServers
+	}
+	if np.Oem != nil && np.Oem.Syslog != nil {
+		haveSyslog = np.Oem.Syslog.SyslogServers
+	}
+	return (len(wantNTP) > 0 && !anyInCommon(wantNTP, haveNTP)) ||
+		(len(wantSyslog) > 0 && !anyInCommon(wantSyslog, haveSyslog))
+}
+
+// anyInCommon says whether two lists share an entry.
+func anyInCommon(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// reinitReason works out whether a present endpoint that just answered a
+// Redfish ping needs reinitializing: because a different BMC now answers
+// for it, or because it came back after being unreachable with factory
+// settings.  It returns "" if it doesn't.  The caller must hold
+// ne.HSMPresLock.
+func reinitReason(ctx context.Context, ne *NetEndpoint, address string, reconnected bool) string {
+	fingerprint := bmcFingerprint(ne)
+	if fingerprint != "" && ne.fingerprint != "" && fingerprint != ne.fingerprint {
+		log.Printf("INFO: %s now has service root UUID %s instead of %s", ne.name, fingerprint, ne.fingerprint)
+		return REINIT_FINGERPRINT
+	}
+	if fingerprint != "" {
+		ne.fingerprint = fingerprint
+	}
+
+	if reconnected && ne.hwtype != TYPE_ENV_CONTROLLER && checkFactorySettings(ctx, ne.name, address) {
+		log.Printf("INFO: %s came back without the NTP or syslog settings MEDS pushed to it", ne.name)
+		return REINIT_FACTORY_SETTINGS
+	}
+	return ""
+}
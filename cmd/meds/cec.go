@@ -0,0 +1,122 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	sls_common "github.com/Cray-HPE/hms-sls/v2/pkg/sls-common"
+	"github.com/Cray-HPE/hms-xname/xnames"
+	"github.com/Cray-HPE/hms-xname/xnametypes"
+)
+
+// If true, discover and register the environmental controllers (CECs) of
+// each cabinet.
+var cecDiscovery = false
+
+// CECProperties are the optional ExtraProperties of a CEC in SLS.  MEDS
+// doesn't generate MAC addresses for CECs, so only a CEC with a MACAddr
+// gets an EthernetInterface in HSM, and one without an IPAddress is found
+// by its xname.
+type CECProperties struct {
+	IPAddress string `json:"IPAddress,omitempty"`
+	MACAddr   string `json:"MACAddr,omitempty"`
+}
+
+// cabinetCECEndpoints generates the CEC endpoints of a cabinet.  If SLS
+// has CECs for the cabinet only those are used, with any address or MAC
+// address SLS gives them; otherwise MTN_eC_COUNT are generated.
+func cabinetCECEndpoints(cabinet sls_common.GenericHardware, cecs []sls_common.GenericHardware) ([]*NetEndpoint, error) {
+	cabinetXname, ok := xnames.FromString(cabinet.Xname).(xnames.Cabinet)
+	if !ok {
+		return nil, fmt.Errorf("INTERNAL ERROR, unable to parse cabinet xname '%v'", cabinet.Xname)
+	}
+
+	if len(cecs) == 0 {
+		return GenerateEnvironmentalControllerEndpoints(cabinetXname.Cabinet), nil
+	}
+
+	endpoints := make([]*NetEndpoint, 0, len(cecs))
+	for _, cec := range cecs {
+		xname := xnametypes.NormalizeHMSCompID(cec.Xname)
+		cecXname, ok := xnames.FromString(xname).(xnames.CEC)
+		if !ok || cecXname.Cabinet != cabinetXname.Cabinet {
+			log.Printf("WARNING: Ignoring CEC %s of cabinet %s in SLS", cec.Xname, cabinet.Xname)
+			continue
+		}
+
+		var props CECProperties
+		raw, err := json.Marshal(cec.ExtraPropertiesRaw)
+		if err == nil {
+			err = json.Unmarshal(raw, &props)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("INTERNAL ERROR, can't read ExtraProperties of %s: %v", xname, err)
+		}
+
+		ec := new(NetEndpoint)
+		ec.name = xname
+		ec.mac = props.MACAddr
+		ec.address = props.IPAddress
+		ec.hwtype = TYPE_ENV_CONTROLLER
+		ec.HSMPresence = PRESENCE_NOT_PRESENT
+		endpoints = append(endpoints, ec)
+	}
+	return endpoints, nil
+}
+
+// init_cabinet_cecs adds the EthernetInterfaces of a cabinet's CECs to HSM
+// and starts watching for them.  Must be called with activeEndpointsLock
+// held.
+func init_cabinet_cecs(ctx context.Context, cabinet sls_common.GenericHardware, cecs []sls_common.GenericHardware) error {
+	endpoints, err := cabinetCECEndpoints(cabinet, cecs)
+	if err != nil {
+		return err
+	}
+
+	err = checkMACCollisions(endpoints, activeEndpoints)
+	if err != nil {
+		log.Printf("ERROR: Not initializing CECs of cabinet %s: %v", cabinet.Xname, err)
+		return err
+	}
+
+	hsmEthernetInterfaces, err := dhcpdnsClient.GetAllEthernetInterfaces()
+	recordResult("hsm", "get_ethernet_interfaces", err)
+	if err != nil {
+		return err
+	}
+
+	err = applyEthernetInterfaces(planEthernetInterfaces(endpoints, hsmEthernetInterfaces))
+	if err != nil {
+		return err
+	}
+
+	log.Printf("INFO: Finished adding CEC EthernetInterfaces to HSM for cabinet %s", cabinet.Xname)
+	startWatching(cabinet.Xname, cabinet.Xname, endpoints)
+	return nil
+}
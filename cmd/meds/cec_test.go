@@ -0,0 +1,85 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"testing"
+
+	sls_common "github.com/Cray-HPE/hms-sls/v2/pkg/sls-common"
+)
+
+func Test_cabinetCECEndpoints(t *testing.T) {
+	tests := []struct {
+		description   string
+		cecs          []sls_common.GenericHardware
+		expectNames   []string
+		expectMACs    []string
+		expectAddress []string
+	}{{
+		"No CECs in SLS",
+		nil,
+		[]string{"x9000e0", "x9000e1"},
+		[]string{"", ""},
+		[]string{"", ""},
+	}, {
+		"CECs from SLS",
+		[]sls_common.GenericHardware{{
+			Xname: "x9000e1",
+			ExtraPropertiesRaw: map[string]interface{}{
+				"IPAddress": "10.254.0.5",
+			},
+		}, {
+			Xname: "x9000e0",
+			ExtraPropertiesRaw: map[string]interface{}{
+				"MACAddr": "b4:2e:99:00:00:01",
+			},
+		}, {
+			// Belongs to a different cabinet
+			Xname: "x1000e0",
+		}},
+		[]string{"x9000e1", "x9000e0"},
+		[]string{"", "b4:2e:99:00:00:01"},
+		[]string{"10.254.0.5", ""},
+	}}
+
+	for i, test := range tests {
+		endpoints, err := cabinetCECEndpoints(testPlanCabinet, test.cecs)
+		if err != nil {
+			t.Errorf("Test %v (%s) Failed: Unexpected error: %v", i, test.description, err)
+			continue
+		}
+		if len(endpoints) != len(test.expectNames) {
+			t.Errorf("Test %v (%s) Failed: Expected %d CECs; Received %d", i, test.description, len(test.expectNames), len(endpoints))
+			continue
+		}
+		for j, ne := range endpoints {
+			if ne.name != test.expectNames[j] || ne.mac != test.expectMACs[j] ||
+				ne.address != test.expectAddress[j] || ne.hwtype != TYPE_ENV_CONTROLLER {
+				t.Errorf("Test %v (%s) Failed: Expected %s %s %s; Received %+v", i, test.description,
+					test.expectNames[j], test.expectMACs[j], test.expectAddress[j], ne)
+			}
+		}
+	}
+}
@@ -0,0 +1,244 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	bmc_nwprotocol "github.com/Cray-HPE/hms-bmc-networkprotocol/pkg"
+)
+
+// How often, in seconds, MEDS checks the NetworkProtocol of every present
+// BMC against what it pushed; 0 disables the check.  If complianceRemediate
+// is set, BMCs that have drifted get the NetworkProtocol pushed again.
+var complianceInterval = 0
+var complianceRemediate = false
+
+// The NetworkProtocol settings the compliance check compares.
+const (
+	DRIFT_NTP         = "NTP"
+	DRIFT_SYSLOG      = "Syslog"
+	DRIFT_SSH_ADMIN   = "SSHAdmin"
+	DRIFT_SSH_CONSOLE = "SSHConsole"
+)
+
+// EndpointCompliance is the result of the last compliance check of a BMC.
+type EndpointCompliance struct {
+	Xname      string    `json:"Xname"`
+	CheckedAt  time.Time `json:"CheckedAt"`
+	Compliant  bool      `json:"Compliant"`
+	Drift      []string  `json:"Drift"`
+	Error      string    `json:"Error,omitempty"`
+	Remediated bool      `json:"Remediated,omitempty"`
+}
+
+type EndpointComplianceArray struct {
+	Endpoints []EndpointCompliance `json:"Endpoints"`
+}
+
+// These are swapped out by the unit tests.
+var readNetworkProtocol = readBMCNetworkProtocol
+var applyNetworkProtocol = bmc_nwprotocol.SetXNameNWPInfo
+
+// readBMCNetworkProtocol GETs the NetworkProtocol of a BMC.
+func readBMCNetworkProtocol(ctx context.Context, address, user, pass string) (bmc_nwprotocol.RedfishNWProtocol, error) {
+	var np bmc_nwprotocol.RedfishNWProtocol
+	err := redfishGet(ctx, address, redfishNPSuffix, user, pass, "get_network_protocol", &np)
+	return np, err
+}
+
+// sameStrings says whether two lists have the same entries, in any order.
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string{}, a...)
+	b = append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// compareNetworkProtocol lists the settings in have that differ from want.
+// Only the settings MEDS pushes are compared, and authorized keys only if
+// the BMC reports them.
+func compareNetworkProtocol(want, have bmc_nwprotocol.RedfishNWProtocol) []string {
+	drift := make([]string, 0)
+
+	if want.NTP != nil && len(want.NTP.NTPServers) > 0 {
+		if have.NTP == nil || !sameStrings(want.NTP.NTPServers, have.NTP.NTPServers) ||
+			have.NTP.ProtocolEnabled != want.NTP.ProtocolEnabled {
+			drift = append(drift, DRIFT_NTP)
+		}
+	}
+
+	if want.Oem == nil {
+		return drift
+	}
+	if want.Oem.Syslog != nil && len(want.Oem.Syslog.SyslogServers) > 0 {
+		if have.Oem == nil || have.Oem.Syslog == nil ||
+			!sameStrings(want.Oem.Syslog.SyslogServers, have.Oem.Syslog.SyslogServers) ||
+			have.Oem.Syslog.ProtocolEnabled != want.Oem.Syslog.ProtocolEnabled {
+			drift = append(drift, DRIFT_SYSLOG)
+		}
+	}
+	if have.Oem != nil && keysDrifted(want.Oem.SSHAdmin, have.Oem.SSHAdmin) {
+		drift = append(drift, DRIFT_SSH_ADMIN)
+	}
+	if have.Oem != nil && keysDrifted(want.Oem.SSHConsole, have.Oem.SSHConsole) {
+		drift = append(drift, DRIFT_SSH_CONSOLE)
+	}
+	return drift
+}
+
+// keysDrifted says whether a BMC reports authorized keys other than those
+// MEDS pushes.  Many BMCs never return their authorized keys, so empty
+// keys count as not reported rather than as drift.
+func keysDrifted(want, have *bmc_nwprotocol.SSHAdminData) bool {
+	if want == nil || want.AuthorizedKeys == "" || have == nil || strings.TrimSpace(have.AuthorizedKeys) == "" {
+		return false
+	}
+	return strings.TrimSpace(have.AuthorizedKeys) != strings.TrimSpace(want.AuthorizedKeys)
+}
+
+// checkCompliance compares the NetworkProtocol of a BMC with what MEDS
+// pushes to it and, if complianceRemediate is set and it has drifted,
+// pushes it again.
+func checkCompliance(ctx context.Context, xname, address string) EndpointCompliance {
+	result := EndpointCompliance{Xname: xname, CheckedAt: time.Now(), Drift: make([]string, 0)}
+
+	cred, err := hcs.GetCompCred(xname)
+	recordResult("vault", "get_comp_cred", err)
+	if err == nil && cred.Username == "" {
+		err = fmt.Errorf("no credentials in Vault")
+	}
+	if err != nil {
+		result.Error = fmt.Sprintf("unable to get credentials: %v", err)
+		return result
+	}
+
+	want, _ := desiredNetworkProtocol(xname)
+	have, err := readNetworkProtocol(ctx, address, cred.Username, cred.Password)
+	if err != nil {
+		result.Error = fmt.Sprintf("unable to read NetworkProtocol: %v", err)
+		return result
+	}
+
+	result.Drift = compareNetworkProtocol(want, have)
+	result.Compliant = len(result.Drift) == 0
+	for _, field := range result.Drift {
+		networkProtocolDrift.WithLabelValues(field).Inc()
+	}
+	if result.Compliant {
+		return result
+	}
+
+	log.Printf("WARNING: NetworkProtocol of %s has drifted: %s", xname, strings.Join(result.Drift, ", "))
+	if !complianceRemediate {
+		return result
+	}
+
+	rfClientLock.RLock()
+	err = applyNetworkProtocol(want, address, cred.Username, cred.Password)
+	rfClientLock.RUnlock()
+	recordResult("redfish", "patch_network_protocol", err)
+	if err != nil {
+		result.Error = fmt.Sprintf("unable to reapply NetworkProtocol: %v", err)
+		return result
+	}
+	log.Printf("INFO: Reapplied NetworkProtocol to %s", xname)
+	result.Remediated = true
+	return result
+}
+
+// runCompliancePass checks every BMC that is present in HSM and answered
+// its last Redfish ping, at most probeWorkers at a time.
+func runCompliancePass(ctx context.Context) {
+	type target struct {
+		ne      *NetEndpoint
+		address string
+	}
+	targets := make([]target, 0)
+
+	activeEndpointsLock.Lock()
+	for _, ne := range activeEndpoints {
+		ne.HSMPresLock.Lock()
+		if ne.hwtype != TYPE_ENV_CONTROLLER && ne.HSMPresence == PRESENCE_PRESENT &&
+			!ne.lastPing.IsZero() && ne.lastPingPresence == PRESENCE_PRESENT {
+			address := ne.name
+			if ne.address != "" {
+				address = ne.address
+			}
+			targets = append(targets, target{ne, address})
+		}
+		ne.HSMPresLock.Unlock()
+	}
+	activeEndpointsLock.Unlock()
+
+	log.Printf("INFO: Checking NetworkProtocol compliance of %d BMCs", len(targets))
+	sem := make(chan struct{}, max(probeWorkers, 1))
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(t target) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result := checkCompliance(ctx, t.ne.name, t.address)
+			t.ne.HSMPresLock.Lock()
+			t.ne.compliance = result
+			t.ne.HSMPresLock.Unlock()
+		}(t)
+	}
+	wg.Wait()
+}
+
+// watchCompliance runs a compliance pass every complianceInterval seconds
+// until ctx is cancelled.
+func watchCompliance(ctx context.Context) {
+	for {
+		select {
+		case <-time.After(time.Duration(complianceInterval) * time.Second):
+		case <-ctx.Done():
+			log.Printf("INFO: Quitting NetworkProtocol compliance thread")
+			return
+		}
+		runCompliancePass(ctx)
+	}
+}
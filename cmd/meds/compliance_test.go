@@ -0,0 +1,194 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	bmc_nwprotocol "github.com/Cray-HPE/hms-bmc-networkprotocol/pkg"
+	compcreds "github.com/Cray-HPE/hms-compcredentials"
+
+	"github.com/Cray-HPE/hms-meds/internal/model"
+	mtest "github.com/Cray-HPE/hms-meds/internal/testing"
+)
+
+func testNetworkProtocol(ntp, syslog, key string) bmc_nwprotocol.RedfishNWProtocol {
+	np := bmc_nwprotocol.RedfishNWProtocol{
+		NTP: &bmc_nwprotocol.NTPData{NTPServers: []string{ntp}, ProtocolEnabled: true},
+		Oem: &bmc_nwprotocol.OemData{
+			Syslog: &bmc_nwprotocol.SyslogData{SyslogServers: []string{syslog}, ProtocolEnabled: true},
+		},
+	}
+	if key != "" {
+		np.Oem.SSHAdmin = &bmc_nwprotocol.SSHAdminData{AuthorizedKeys: key}
+		np.Oem.SSHConsole = &bmc_nwprotocol.SSHAdminData{AuthorizedKeys: key}
+	}
+	return np
+}
+
+func Test_compareNetworkProtocol(t *testing.T) {
+	want := testNetworkProtocol("ntp.local", "syslog.local", "ssh-ed25519 AAAA root@ncn")
+
+	tests := []struct {
+		description string
+		have        bmc_nwprotocol.RedfishNWProtocol
+		expectDrift []string
+	}{{
+		"Compliant",
+		testNetworkProtocol("ntp.local", "syslog.local", "ssh-ed25519 AAAA root@ncn\n"),
+		[]string{},
+	}, {
+		"Keys not reported",
+		testNetworkProtocol("ntp.local", "syslog.local", ""),
+		[]string{},
+	}, {
+		"NTP changed",
+		testNetworkProtocol("pool.ntp.org", "syslog.local", "ssh-ed25519 AAAA root@ncn"),
+		[]string{DRIFT_NTP},
+	}, {
+		"Factory settings",
+		bmc_nwprotocol.RedfishNWProtocol{Oem: &bmc_nwprotocol.OemData{
+			SSHAdmin:   &bmc_nwprotocol.SSHAdminData{},
+			SSHConsole: &bmc_nwprotocol.SSHAdminData{},
+		}},
+		[]string{DRIFT_NTP, DRIFT_SYSLOG},
+	}, {
+		"Other keys",
+		testNetworkProtocol("ntp.local", "syslog.local", "ssh-rsa BBBB admin@elsewhere"),
+		[]string{DRIFT_SSH_ADMIN, DRIFT_SSH_CONSOLE},
+	}}
+
+	for i, test := range tests {
+		drift := compareNetworkProtocol(want, test.have)
+		if !sameStrings(drift, test.expectDrift) {
+			t.Errorf("Test %v (%s) Failed: Expected drift %v; Received %v", i, test.description, test.expectDrift, drift)
+		}
+	}
+}
+
+func Test_checkCompliance(t *testing.T) {
+	defer func() {
+		readNetworkProtocol = readBMCNetworkProtocol
+		applyNetworkProtocol = bmc_nwprotocol.SetXNameNWPInfo
+		complianceRemediate = false
+		rfNWPStatic = bmc_nwprotocol.RedfishNWProtocol{}
+	}()
+
+	ss := mtest.NewKvMock()
+	hcs = compcreds.NewCompCredStore("hms-creds", ss)
+	credStorage = model.NewMedsCredStore(model.CredentialsKeyPrefix, ss)
+	hcs.StoreCompCred(compcreds.CompCredentials{Xname: "x1000c0s0b0", Username: "root", Password: "secret"})
+	rfNWPStatic = testNetworkProtocol("ntp.local", "syslog.local", "")
+
+	tests := []struct {
+		description      string
+		remediate        bool
+		have             bmc_nwprotocol.RedfishNWProtocol
+		readErr          error
+		applyErr         error
+		expectCompliant  bool
+		expectApplied    bool
+		expectRemediated bool
+		expectErr        bool
+	}{{
+		"Compliant",
+		true, testNetworkProtocol("ntp.local", "syslog.local", ""), nil, nil,
+		true, false, false, false,
+	}, {
+		"Drift reported only",
+		false, testNetworkProtocol("pool.ntp.org", "syslog.local", ""), nil, nil,
+		false, false, false, false,
+	}, {
+		"Drift remediated",
+		true, testNetworkProtocol("pool.ntp.org", "syslog.local", ""), nil, nil,
+		false, true, true, false,
+	}, {
+		"Remediation fails",
+		true, testNetworkProtocol("pool.ntp.org", "syslog.local", ""), nil, errors.New("PATCH failed"),
+		false, true, false, true,
+	}, {
+		"BMC can't be read",
+		true, bmc_nwprotocol.RedfishNWProtocol{}, errors.New("401"), nil,
+		false, false, false, true,
+	}}
+
+	for i, test := range tests {
+		complianceRemediate = test.remediate
+		readNetworkProtocol = func(ctx context.Context, address, user, pass string) (bmc_nwprotocol.RedfishNWProtocol, error) {
+			if user != "root" || pass != "secret" {
+				t.Errorf("Test %v (%s) Failed: Read with credentials %s/%s", i, test.description, user, pass)
+			}
+			return test.have, test.readErr
+		}
+		applied := false
+		applyNetworkProtocol = func(np bmc_nwprotocol.RedfishNWProtocol, address, user, pass string) error {
+			applied = true
+			if np.NTP == nil || np.NTP.NTPServers[0] != "ntp.local" {
+				t.Errorf("Test %v (%s) Failed: Applied the wrong NetworkProtocol %+v", i, test.description, np)
+			}
+			return test.applyErr
+		}
+
+		result := checkCompliance(context.Background(), "x1000c0s0b0", "x1000c0s0b0")
+		if result.Compliant != test.expectCompliant || applied != test.expectApplied ||
+			result.Remediated != test.expectRemediated || (result.Error != "") != test.expectErr {
+			t.Errorf("Test %v (%s) Failed: Expected compliant %v, applied %v, remediated %v, error %v; Received %+v (applied %v)",
+				i, test.description, test.expectCompliant, test.expectApplied, test.expectRemediated, test.expectErr, result, applied)
+		}
+	}
+}
+
+func Test_doComplianceGet(t *testing.T) {
+	checkedAt := time.Now()
+	activeEndpoints = map[string]*NetEndpoint{
+		"x1000c0s0b0": {name: "x1000c0s0b0", compliance: EndpointCompliance{
+			Xname: "x1000c0s0b0", CheckedAt: checkedAt, Compliant: true, Drift: []string{}}},
+		"x1000c0s0b1": {name: "x1000c0s0b1", compliance: EndpointCompliance{
+			Xname: "x1000c0s0b1", CheckedAt: checkedAt, Drift: []string{DRIFT_NTP}}},
+		// Not checked yet
+		"x1000c0s1b0": {name: "x1000c0s1b0"},
+	}
+
+	for _, test := range []struct {
+		query       string
+		expectCount int
+	}{{"", 2}, {"?drifted=true", 1}} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/compliance"+test.query, nil)
+		w := httptest.NewRecorder()
+		newRouter().ServeHTTP(w, req)
+
+		var results EndpointComplianceArray
+		err := json.Unmarshal(w.Body.Bytes(), &results)
+		if w.Code != http.StatusOK || err != nil || len(results.Endpoints) != test.expectCount {
+			t.Errorf("GET /v1/compliance%s: Expected %d endpoints; Received %d %s", test.query, test.expectCount, w.Code, w.Body.String())
+		}
+	}
+}
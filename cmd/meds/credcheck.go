@@ -0,0 +1,157 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	compcreds "github.com/Cray-HPE/hms-compcredentials"
+)
+
+// What to do about BMCs whose Vault credentials don't work.
+const (
+	CREDENTIAL_CHECK_OFF    = "off"    // Don't check
+	CREDENTIAL_CHECK_WARN   = "warn"   // Log, count and report failures, but register anyway
+	CREDENTIAL_CHECK_REPAIR = "repair" // Also try the MEDS credentials and store them if they work
+)
+
+var credentialCheck = CREDENTIAL_CHECK_WARN
+
+// validCredentialCheck checks a credential check mode.
+func validCredentialCheck(mode string) bool {
+	switch mode {
+	case CREDENTIAL_CHECK_OFF, CREDENTIAL_CHECK_WARN, CREDENTIAL_CHECK_REPAIR:
+		return true
+	}
+	return false
+}
+
+// The results of a credential check.
+const (
+	CREDENTIALS_OK          = "ok"          // The Vault credentials work
+	CREDENTIALS_AUTH_FAILED = "auth_failed" // The BMC rejected the Vault credentials
+	CREDENTIALS_REPAIRED    = "repaired"    // The MEDS credentials work and are now in Vault
+	CREDENTIALS_ERROR       = "error"       // The BMC couldn't be asked
+)
+
+// CredentialCheck is the result of the last check of a BMC's Vault
+// credentials.
+type CredentialCheck struct {
+	Xname     string    `json:"Xname"`
+	CheckedAt time.Time `json:"CheckedAt"`
+	Result    string    `json:"Result"`
+	Error     string    `json:"Error,omitempty"`
+	Source    string    `json:"Source,omitempty"` // Where repaired credentials came from
+}
+
+type CredentialCheckArray struct {
+	Endpoints []CredentialCheck `json:"Endpoints"`
+}
+
+// tryCredentials does an authenticated GET of a BMC's managers, which HSM
+// needs to be able to do to discover it.
+func tryCredentials(ctx context.Context, address, user, pass string) error {
+	var managers RedfishCollection
+	return redfishGet(ctx, address, "/redfish/v1/Managers", user, pass, "get_managers", &managers)
+}
+
+// repairCredentials tries the credentials MEDS resolves for a BMC whose
+// Vault credentials don't work, and stores them in Vault if they do.  The
+// Vault key they came from is returned with them.
+func repairCredentials(ctx context.Context, xname, address string, cred compcreds.CompCredentials) (compcreds.CompCredentials, string, error) {
+	resolvedCreds, source, err := resolveCredentials(xname)
+	if err != nil {
+		return cred, source, err
+	}
+	if resolvedCreds.Username == cred.Username && resolvedCreds.Password == cred.Password {
+		return cred, source, fmt.Errorf("the credentials from %s are the ones that failed", source)
+	}
+
+	err = tryCredentials(ctx, address, resolvedCreds.Username, resolvedCreds.Password)
+	if err != nil {
+		return cred, source, fmt.Errorf("the credentials from %s don't work either: %v", source, err)
+	}
+
+	repaired := cred
+	repaired.Username = resolvedCreds.Username
+	repaired.Password = resolvedCreds.Password
+	err = hcs.StoreCompCred(repaired)
+	recordResult("vault", "store_comp_cred", err)
+	if err != nil {
+		return cred, source, fmt.Errorf("unable to store the credentials from %s in Vault: %v", source, err)
+	}
+	return repaired, source, nil
+}
+
+// verifyCredentials checks that a BMC accepts its Vault credentials before
+// it is registered with HSM, and records the result.  In repair mode the
+// MEDS credentials are tried if they don't, and returned if they work.
+// The BMC is registered whatever the result.  The caller must hold
+// node.HSMPresLock.
+func verifyCredentials(ctx context.Context, node *NetEndpoint, address string, cred compcreds.CompCredentials) compcreds.CompCredentials {
+	if credentialCheck == CREDENTIAL_CHECK_OFF {
+		return cred
+	}
+
+	check := CredentialCheck{
+		Xname:     node.name,
+		CheckedAt: time.Now(),
+		Result:    CREDENTIALS_OK,
+	}
+	err := tryCredentials(ctx, address, cred.Username, cred.Password)
+	switch {
+	case err == nil:
+	case !isAuthFailure(err):
+		log.Printf("WARNING: Unable to check the credentials of %s: %v", node.name, err)
+		check.Result = CREDENTIALS_ERROR
+		check.Error = err.Error()
+	default:
+		log.Printf("ERROR: %s does not accept its credentials from Vault; HSM will be unable to discover it: %v",
+			node.name, err)
+		check.Result = CREDENTIALS_AUTH_FAILED
+		check.Error = err.Error()
+		if credentialCheck != CREDENTIAL_CHECK_REPAIR {
+			break
+		}
+		repaired, source, rerr := repairCredentials(ctx, node.name, address, cred)
+		if rerr != nil {
+			log.Printf("ERROR: Unable to repair the credentials of %s: %v", node.name, rerr)
+			check.Error += "; unable to repair: " + rerr.Error()
+			break
+		}
+		log.Printf("INFO: Stored the MEDS credentials from %s in Vault for %s", source, node.name)
+		check.Result = CREDENTIALS_REPAIRED
+		check.Error = ""
+		check.Source = source
+		cred = repaired
+	}
+
+	credentialCheckResults.WithLabelValues(check.Result).Inc()
+	node.credentialCheck = check
+	return cred
+}
@@ -0,0 +1,178 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/hms-certs/pkg/hms_certs"
+	compcreds "github.com/Cray-HPE/hms-compcredentials"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Cray-HPE/hms-meds/internal/model"
+	mtest "github.com/Cray-HPE/hms-meds/internal/testing"
+)
+
+func Test_verifyCredentials(t *testing.T) {
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "root" || pass != "initial0" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/redfish/v1/Managers" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"Members":[{"@odata.id":"/redfish/v1/Managers/BMC"}]}`))
+	}))
+	defer testServer.Close()
+	address := strings.Split(testServer.URL, "//")[1]
+	client, _ = hms_certs.CreateHTTPClientPair("", clientTimeout)
+	setupRFHTTPStuff()
+	defer func() {
+		credentialCheck = CREDENTIAL_CHECK_WARN
+	}()
+
+	node := NetEndpoint{
+		name:   "x1000c0s0b0",
+		hwtype: TYPE_NODE_CARD,
+	}
+
+	tests := []struct {
+		description    string
+		mode           string
+		vaultPassword  string
+		globalPassword string
+		address        string
+		expectResult   string
+		expectPassword string
+	}{
+		{"Credentials work", CREDENTIAL_CHECK_WARN, "initial0", "initial0", address,
+			CREDENTIALS_OK, "initial0"},
+		{"Auth failure reported", CREDENTIAL_CHECK_WARN, "wrong", "initial0", address,
+			CREDENTIALS_AUTH_FAILED, "wrong"},
+		{"Auth failure repaired", CREDENTIAL_CHECK_REPAIR, "wrong", "initial0", address,
+			CREDENTIALS_REPAIRED, "initial0"},
+		{"Global credentials fail too", CREDENTIAL_CHECK_REPAIR, "wrong", "alsowrong", address,
+			CREDENTIALS_AUTH_FAILED, "wrong"},
+		{"BMC unreachable", CREDENTIAL_CHECK_REPAIR, "wrong", "initial0", "127.0.0.1:1",
+			CREDENTIALS_ERROR, "wrong"},
+		{"Check off", CREDENTIAL_CHECK_OFF, "wrong", "initial0", address,
+			"", "wrong"},
+	}
+
+	for i, test := range tests {
+		ss := mtest.NewKvMock()
+		hcs = compcreds.NewCompCredStore("hms-creds", ss)
+		credStorage = model.NewMedsCredStore(model.CredentialsKeyPrefix, ss)
+		credStorage.StoreGlobalCredentials(model.MedsCredentials{Username: "root", Password: test.globalPassword})
+		cred := compcreds.CompCredentials{Xname: node.name, Username: "root", Password: test.vaultPassword}
+		hcs.StoreCompCred(cred)
+		node.credentialCheck = CredentialCheck{}
+		credentialCheck = test.mode
+
+		before := testutil.ToFloat64(credentialCheckResults.WithLabelValues(test.expectResult))
+		cred = verifyCredentials(context.Background(), &node, test.address, cred)
+
+		if cred.Password != test.expectPassword {
+			t.Errorf("Test %v (%s) Failed: Expected password %q; Received %q",
+				i, test.description, test.expectPassword, cred.Password)
+		}
+		stored, _ := hcs.GetCompCred(node.name)
+		if stored.Password != test.expectPassword {
+			t.Errorf("Test %v (%s) Failed: Expected Vault password %q; Received %q",
+				i, test.description, test.expectPassword, stored.Password)
+		}
+		check := node.credentialCheck
+		if test.expectResult == "" {
+			if !check.CheckedAt.IsZero() {
+				t.Errorf("Test %v (%s) Failed: Expected no check; Received %+v", i, test.description, check)
+			}
+			continue
+		}
+		if check.Result != test.expectResult {
+			t.Errorf("Test %v (%s) Failed: Expected result %s; Received %+v",
+				i, test.description, test.expectResult, check)
+		}
+		if (check.Error != "") != (test.expectResult != CREDENTIALS_OK && test.expectResult != CREDENTIALS_REPAIRED) {
+			t.Errorf("Test %v (%s) Failed: Unexpected error %q", i, test.description, check.Error)
+		}
+		if testutil.ToFloat64(credentialCheckResults.WithLabelValues(test.expectResult))-before != 1 {
+			t.Errorf("Test %v (%s) Failed: Expected the result to be counted", i, test.description)
+		}
+	}
+}
+
+func Test_isAuthFailure(t *testing.T) {
+	tests := []struct {
+		err    error
+		expect bool
+	}{
+		{&RedfishStatusError{Message: "GET", StatusCode: http.StatusUnauthorized}, true},
+		{&RedfishStatusError{Message: "GET", StatusCode: http.StatusForbidden}, true},
+		{&RedfishStatusError{Message: "GET", StatusCode: http.StatusNotFound}, false},
+		{context.DeadlineExceeded, false},
+		{nil, false},
+	}
+	for i, test := range tests {
+		if isAuthFailure(test.err) != test.expect {
+			t.Errorf("Test %v Failed: Expected %v for %v", i, test.expect, test.err)
+		}
+	}
+}
+
+func Test_doCredentialChecksGet(t *testing.T) {
+	checkedAt := time.Now()
+	activeEndpoints = map[string]*NetEndpoint{
+		"x1000c0s0b0": {name: "x1000c0s0b0", credentialCheck: CredentialCheck{
+			Xname: "x1000c0s0b0", CheckedAt: checkedAt, Result: CREDENTIALS_OK}},
+		"x1000c0s0b1": {name: "x1000c0s0b1", credentialCheck: CredentialCheck{
+			Xname: "x1000c0s0b1", CheckedAt: checkedAt, Result: CREDENTIALS_AUTH_FAILED}},
+		// Not checked yet
+		"x1000c0s1b0": {name: "x1000c0s1b0"},
+	}
+
+	for _, test := range []struct {
+		query       string
+		expectCount int
+	}{{"", 2}, {"?failed=true", 1}} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/credentials"+test.query, nil)
+		w := httptest.NewRecorder()
+		newRouter().ServeHTTP(w, req)
+
+		var checks CredentialCheckArray
+		err := json.Unmarshal(w.Body.Bytes(), &checks)
+		if w.Code != http.StatusOK || err != nil || len(checks.Endpoints) != test.expectCount {
+			t.Errorf("GET /v1/credentials%s: Expected %d endpoints; Received %d %s", test.query, test.expectCount, w.Code, w.Body.String())
+		}
+	}
+}
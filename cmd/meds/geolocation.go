@@ -0,0 +1,196 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Cray-HPE/hms-xname/xnametypes"
+
+	"github.com/Cray-HPE/hms-meds/internal/macaddr"
+)
+
+// What to do when a BMC doesn't appear to be where MEDS thinks it is.
+const (
+	GEOLOCATION_OFF     = "off"     // Don't check
+	GEOLOCATION_WARN    = "warn"    // Log and count mismatches, but register anyway
+	GEOLOCATION_ENFORCE = "enforce" // Refuse to register mismatched BMCs
+)
+
+var geolocationCheck = GEOLOCATION_WARN
+
+// validGeolocationCheck checks a geolocation check mode.
+func validGeolocationCheck(mode string) bool {
+	switch mode {
+	case GEOLOCATION_OFF, GEOLOCATION_WARN, GEOLOCATION_ENFORCE:
+		return true
+	}
+	return false
+}
+
+// BMCGeolocation is what a BMC reports about itself: the MAC addresses of
+// its managers and the locations of the chassis they manage.
+type BMCGeolocation struct {
+	MACs      []string
+	Locations []string
+}
+
+// readBMCGeolocation reads the manager MAC addresses and chassis locations
+// from the BMC at address.
+func readBMCGeolocation(ctx context.Context, address, user, pass string) (BMCGeolocation, error) {
+	var geo BMCGeolocation
+
+	var managers RedfishCollection
+	err := redfishGet(ctx, address, "/redfish/v1/Managers", user, pass, "get_managers", &managers)
+	if err != nil {
+		return geo, err
+	}
+
+	for _, managerLink := range managers.Members {
+		var manager RedfishManager
+		err = redfishGet(ctx, address, managerLink.OdataID, user, pass, "get_manager", &manager)
+		if err != nil {
+			return geo, err
+		}
+
+		if manager.EthernetInterfaces.OdataID != "" {
+			var interfaces RedfishCollection
+			err = redfishGet(ctx, address, manager.EthernetInterfaces.OdataID, user, pass,
+				"get_manager_ethernet_interfaces", &interfaces)
+			if err != nil {
+				return geo, err
+			}
+			for _, eiLink := range interfaces.Members {
+				var ei RedfishEthernetInterface
+				err = redfishGet(ctx, address, eiLink.OdataID, user, pass,
+					"get_manager_ethernet_interface", &ei)
+				if err != nil {
+					return geo, err
+				}
+				for _, mac := range []string{ei.MACAddress, ei.PermanentMACAddress} {
+					if mac != "" {
+						geo.MACs = append(geo.MACs, macaddr.Normalize(mac))
+					}
+				}
+			}
+		}
+
+		for _, chassisLink := range manager.Links.ManagerForChassis {
+			var chassis RedfishChassis
+			err = redfishGet(ctx, address, chassisLink.OdataID, user, pass, "get_chassis", &chassis)
+			if err != nil {
+				return geo, err
+			}
+			if label := chassis.Location.PartLocation.ServiceLabel; label != "" {
+				geo.Locations = append(geo.Locations, label)
+			}
+		}
+	}
+	return geo, nil
+}
+
+// xnamesRelated reports whether one xname is the other or one of its
+// ancestors.
+func xnamesRelated(a, b string) bool {
+	for _, pair := range [][2]string{{a, b}, {b, a}} {
+		for x := pair[0]; x != ""; x = xnametypes.GetHMSCompParent(x) {
+			if x == pair[1] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkGeolocation compares what a BMC reports about itself to the xname
+// and MAC address MEDS generated for it.  MAC addresses and locations the
+// BMC doesn't report can't disagree.  The mismatched check ("mac" or
+// "location") is returned with the error.
+func checkGeolocation(node *NetEndpoint, geo BMCGeolocation) (string, error) {
+	expectedMAC := macaddr.Normalize(node.mac)
+	if expectedMAC != "" && len(geo.MACs) > 0 {
+		found := false
+		for _, mac := range geo.MACs {
+			if mac == expectedMAC {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "mac", fmt.Errorf("BMC at %s reports MAC address(es) %s, expected %s",
+				node.name, strings.Join(geo.MACs, ", "), expectedMAC)
+		}
+	}
+
+	// Only locations that are xnames can be checked, and the BMC is where
+	// we think it is if any of them is the BMC, one of its parents or one
+	// of its children.
+	locations := make([]string, 0, len(geo.Locations))
+	for _, location := range geo.Locations {
+		location = xnametypes.NormalizeHMSCompID(location)
+		if !xnametypes.IsHMSCompIDValid(location) {
+			continue
+		}
+		if xnamesRelated(node.name, location) {
+			return "", nil
+		}
+		locations = append(locations, location)
+	}
+	if len(locations) > 0 {
+		return "location", fmt.Errorf("BMC at %s reports location(s) %s",
+			node.name, strings.Join(locations, ", "))
+	}
+	return "", nil
+}
+
+// verifyGeolocation checks that the BMC answering at address is really the
+// one MEDS expects there.  It only returns an error for a mismatch in
+// enforce mode; a BMC that can't be read is registered anyway.
+func verifyGeolocation(ctx context.Context, node *NetEndpoint, address, user, pass string) error {
+	if geolocationCheck == GEOLOCATION_OFF {
+		return nil
+	}
+
+	geo, err := readBMCGeolocation(ctx, address, user, pass)
+	if err != nil {
+		log.Printf("WARNING: Unable to verify the geolocation of %s: %v", node.name, err)
+		return nil
+	}
+
+	check, err := checkGeolocation(node, geo)
+	if err == nil {
+		return nil
+	}
+	geolocationMismatches.WithLabelValues(check).Inc()
+	if geolocationCheck == GEOLOCATION_ENFORCE {
+		log.Printf("ERROR: Geolocation mismatch, not registering %s: %v", node.name, err)
+		return err
+	}
+	log.Printf("ERROR: Geolocation mismatch for %s, registering anyway: %v", node.name, err)
+	return nil
+}
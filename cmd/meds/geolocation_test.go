@@ -0,0 +1,150 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-certs/pkg/hms_certs"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func Test_checkGeolocation(t *testing.T) {
+	node := NetEndpoint{
+		name:   "x1000c3s5b0",
+		mac:    "02:03:E8:03:50:00",
+		hwtype: TYPE_NODE_CARD,
+	}
+
+	tests := []struct {
+		description string
+		geo         BMCGeolocation
+		expectCheck string
+	}{{
+		"Nothing reported",
+		BMCGeolocation{},
+		"",
+	}, {
+		"MAC and blade location match",
+		BMCGeolocation{MACs: []string{"0203e8035000"}, Locations: []string{"x1000c3s5"}},
+		"",
+	}, {
+		"Node locations match",
+		BMCGeolocation{Locations: []string{"x1000c3s5b0n0", "x1000c3s5b0n1"}},
+		"",
+	}, {
+		"Locations that aren't xnames are ignored",
+		BMCGeolocation{Locations: []string{"Slot 5"}},
+		"",
+	}, {
+		"Wrong MAC",
+		BMCGeolocation{MACs: []string{"0203e8036000"}, Locations: []string{"x1000c3s5"}},
+		"mac",
+	}, {
+		"Wrong slot",
+		BMCGeolocation{MACs: []string{"0203e8035000"}, Locations: []string{"x1000c3s6"}},
+		"location",
+	}}
+
+	for i, test := range tests {
+		check, err := checkGeolocation(&node, test.geo)
+		if check != test.expectCheck {
+			t.Errorf("Test %v (%s) Failed: Expected mismatched check '%s'; Received '%s' (%v)", i, test.description, test.expectCheck, check, err)
+		}
+		if (err != nil) != (test.expectCheck != "") {
+			t.Errorf("Test %v (%s) Failed: Unexpected error result: %v", i, test.description, err)
+		}
+	}
+}
+
+func Test_verifyGeolocation(t *testing.T) {
+	responses := map[string]string{
+		"/redfish/v1/Managers":                             `{"Members":[{"@odata.id":"/redfish/v1/Managers/BMC"}]}`,
+		"/redfish/v1/Managers/BMC":                         `{"Id":"BMC","EthernetInterfaces":{"@odata.id":"/redfish/v1/Managers/BMC/EthernetInterfaces"},"Links":{"ManagerForChassis":[{"@odata.id":"/redfish/v1/Chassis/Blade0"}]}}`,
+		"/redfish/v1/Managers/BMC/EthernetInterfaces":      `{"Members":[{"@odata.id":"/redfish/v1/Managers/BMC/EthernetInterfaces/eth0"}]}`,
+		"/redfish/v1/Managers/BMC/EthernetInterfaces/eth0": `{"Id":"eth0","MACAddress":"02:03:E8:03:50:00"}`,
+		"/redfish/v1/Chassis/Blade0":                       `{"Id":"Blade0","Location":{"PartLocation":{"ServiceLabel":"x1000c3s6"}}}`,
+	}
+	serviceName = "MEDS_TEST"
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "root" || pass != "secret" {
+			t.Errorf("Request %s had no credentials", r.URL.String())
+		}
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(json.RawMessage(body))
+	}))
+	defer testServer.Close()
+	address := strings.Split(testServer.URL, "//")[1]
+	client, _ = hms_certs.CreateHTTPClientPair("", clientTimeout)
+	setupRFHTTPStuff()
+
+	node := NetEndpoint{
+		name:   "x1000c3s5b0",
+		mac:    "02:03:E8:03:50:00",
+		hwtype: TYPE_NODE_CARD,
+	}
+
+	tests := []struct {
+		mode      string
+		expectErr bool
+	}{
+		{GEOLOCATION_OFF, false},
+		{GEOLOCATION_WARN, false},
+		{GEOLOCATION_ENFORCE, true},
+	}
+
+	defer func() { geolocationCheck = GEOLOCATION_WARN }()
+	for i, test := range tests {
+		geolocationCheck = test.mode
+		before := testutil.ToFloat64(geolocationMismatches.WithLabelValues("location"))
+		err := verifyGeolocation(context.Background(), &node, address, "root", "secret")
+		if (err != nil) != test.expectErr {
+			t.Errorf("Test %v (%s) Failed: Expected error %v; Received %v", i, test.mode, test.expectErr, err)
+		}
+		mismatches := testutil.ToFloat64(geolocationMismatches.WithLabelValues("location")) - before
+		if test.mode == GEOLOCATION_OFF && mismatches != 0 {
+			t.Errorf("Test %v (%s) Failed: Expected no mismatch to be counted", i, test.mode)
+		} else if test.mode != GEOLOCATION_OFF && mismatches != 1 {
+			t.Errorf("Test %v (%s) Failed: Expected a mismatch to be counted; Received %v", i, test.mode, mismatches)
+		}
+	}
+
+	// A BMC that can't be read is registered anyway.
+	delete(responses, "/redfish/v1/Managers")
+	err := verifyGeolocation(context.Background(), &node, address, "root", "secret")
+	if err != nil {
+		t.Errorf("Expected an unreadable BMC to be registered anyway; Received %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// How long after the last successful SLS query MEDS still considers
+// itself ready.  The SLS loop backs off to at most 5 minutes between
+// attempts, so this allows for a couple of failed attempts.
+var slsReadyWindow = 600 // In seconds
+
+// serviceHealth tracks the connectivity MEDS needs in order to do its job.
+type serviceHealth struct {
+	lock           sync.Mutex
+	vaultConnected bool
+	vaultError     string
+	initialHSMSync bool
+	lastHSMSync    time.Time
+	lastHSMError   string
+	lastSLSSuccess time.Time
+	lastSLSError   string
+	rfTLSValidated bool
+	shuttingDown   bool
+}
+
+var medsHealth serviceHealth
+
+// ReadinessCheck is the result of a single readiness check.  Checks
+// marked Informational are reported but do not affect overall readiness.
+type ReadinessCheck struct {
+	Name          string `json:"Name"`
+	Ready         bool   `json:"Ready"`
+	Informational bool   `json:"Informational,omitempty"`
+	Detail        string `json:"Detail,omitempty"`
+}
+
+type ReadinessStatus struct {
+	Ready  bool             `json:"Ready"`
+	Checks []ReadinessCheck `json:"Checks"`
+}
+
+func (h *serviceHealth) setVaultStatus(err error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.vaultConnected = err == nil
+	if err != nil {
+		h.vaultError = err.Error()
+	} else {
+		h.vaultError = ""
+	}
+}
+
+func (h *serviceHealth) setHSMSyncStatus(lastSuccess time.Time, lastError string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.lastHSMSync = lastSuccess
+	h.lastHSMError = lastError
+	if !lastSuccess.IsZero() {
+		h.initialHSMSync = true
+	}
+}
+
+func (h *serviceHealth) setSLSStatus(err error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if err != nil {
+		h.lastSLSError = err.Error()
+	} else {
+		h.lastSLSSuccess = time.Now()
+		h.lastSLSError = ""
+	}
+}
+
+func (h *serviceHealth) setRFTLSValidated(validated bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.rfTLSValidated = validated
+}
+
+func (h *serviceHealth) setShuttingDown(shuttingDown bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.shuttingDown = shuttingDown
+}
+
+func (h *serviceHealth) isShuttingDown() bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.shuttingDown
+}
+
+// readiness evaluates every readiness check as of 'now'.
+func (h *serviceHealth) readiness(now time.Time) ReadinessStatus {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	vault := ReadinessCheck{Name: "vault", Ready: h.vaultConnected}
+	if !h.vaultConnected {
+		vault.Detail = "not connected to Vault"
+		if h.vaultError != "" {
+			vault.Detail += ": " + h.vaultError
+		}
+	}
+
+	hsmCheck := ReadinessCheck{Name: "hsm", Ready: h.initialHSMSync}
+	if !h.initialHSMSync {
+		hsmCheck.Detail = "initial sync with HSM has not completed"
+	} else if !h.lastHSMSync.IsZero() {
+		hsmCheck.Detail = "last successful sync with HSM was at " +
+			h.lastHSMSync.Format(time.RFC3339)
+	}
+	if h.lastHSMError != "" {
+		hsmCheck.Detail += "; last error: " + h.lastHSMError
+	}
+
+	window := time.Duration(slsReadyWindow) * time.Second
+	slsCheck := ReadinessCheck{Name: "sls"}
+	if h.lastSLSSuccess.IsZero() {
+		slsCheck.Detail = "no successful SLS query yet"
+	} else if now.Sub(h.lastSLSSuccess) > window {
+		slsCheck.Detail = fmt.Sprintf("last successful SLS query was at %s",
+			h.lastSLSSuccess.Format(time.RFC3339))
+	} else {
+		slsCheck.Ready = true
+	}
+	if !slsCheck.Ready && h.lastSLSError != "" {
+		slsCheck.Detail += "; last error: " + h.lastSLSError
+	}
+
+	rfCheck := ReadinessCheck{Name: "redfish-tls", Ready: h.rfTLSValidated, Informational: true}
+	if h.rfTLSValidated {
+		rfCheck.Detail = "Redfish client is TLS-validated"
+	} else {
+		rfCheck.Detail = "Redfish client is not TLS-validated (insecure)"
+	}
+
+	status := ReadinessStatus{Checks: []ReadinessCheck{vault, hsmCheck, slsCheck, rfCheck}}
+	if h.shuttingDown {
+		status.Checks = append(status.Checks,
+			ReadinessCheck{Name: "shutdown", Detail: "MEDS is shutting down"})
+	}
+	status.Ready = true
+	for _, check := range status.Checks {
+		if !check.Informational && !check.Ready {
+			status.Ready = false
+		}
+	}
+	return status
+}
+
+// GET /healthz
+//
+// Liveness only reflects that MEDS is running and able to answer requests.
+func doLivenessGet(w http.ResponseWriter, r *http.Request) {
+	sendJSONResponse(w, http.StatusOK, map[string]string{"Status": "OK"})
+}
+
+// GET /readyz
+func doReadinessGet(w http.ResponseWriter, r *http.Request) {
+	status := medsHealth.readiness(time.Now())
+	if status.Ready {
+		sendJSONResponse(w, http.StatusOK, status)
+	} else {
+		sendJSONResponse(w, http.StatusServiceUnavailable, status)
+	}
+}
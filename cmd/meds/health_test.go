@@ -0,0 +1,167 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/hms-certs/pkg/hms_certs"
+)
+
+func Test_serviceHealth_readiness(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		description string
+		health      serviceHealth
+		expectReady bool
+	}{{
+		"Nothing has happened yet",
+		serviceHealth{},
+		false,
+	}, {
+		"Everything healthy, Redfish client insecure",
+		serviceHealth{
+			vaultConnected: true,
+			initialHSMSync: true,
+			lastSLSSuccess: now.Add(-30 * time.Second),
+		},
+		true,
+	}, {
+		"Initial HSM sync not complete",
+		serviceHealth{
+			vaultConnected: true,
+			lastSLSSuccess: now.Add(-30 * time.Second),
+			rfTLSValidated: true,
+		},
+		false,
+	}, {
+		"SLS stale",
+		serviceHealth{
+			vaultConnected: true,
+			initialHSMSync: true,
+			lastSLSSuccess: now.Add(-time.Duration(slsReadyWindow+1) * time.Second),
+			lastSLSError:   "connection refused",
+			rfTLSValidated: true,
+		},
+		false,
+	}, {
+		"Vault not connected",
+		serviceHealth{
+			vaultError:     "permission denied",
+			initialHSMSync: true,
+			lastSLSSuccess: now,
+		},
+		false,
+	}}
+
+	for i := range tests {
+		test := &tests[i]
+		status := test.health.readiness(now)
+		if status.Ready != test.expectReady {
+			t.Errorf("Test %v (%s) Failed: Expected ready=%v; Received %+v", i, test.description, test.expectReady, status)
+		}
+		if len(status.Checks) != 4 {
+			t.Errorf("Test %v (%s) Failed: Expected 4 checks; Received %d", i, test.description, len(status.Checks))
+		}
+	}
+}
+
+func Test_doReadinessGet(t *testing.T) {
+	medsHealth = serviceHealth{}
+
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	w := httptest.NewRecorder()
+	newRouter().ServeHTTP(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 before initialization, got %d", w.Code)
+	}
+
+	medsHealth.setVaultStatus(nil)
+	medsHealth.setHSMSyncStatus(time.Now(), "")
+	medsHealth.setSLSStatus(errors.New("dummy"))
+	medsHealth.setSLSStatus(nil)
+
+	w = httptest.NewRecorder()
+	newRouter().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200 once initialized, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	w = httptest.NewRecorder()
+	newRouter().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200 from /healthz, got %d", w.Code)
+	}
+}
+
+func Test_validatesTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0600); err != nil {
+		t.Fatalf("Can't write CA bundle: %v", err)
+	}
+
+	insecure, _ := hms_certs.CreateHTTPClientPair("", clientTimeout)
+	secure, err := hms_certs.CreateHTTPClientPair(caFile, clientTimeout)
+	if err != nil {
+		t.Fatalf("Can't create TLS-validated client: %v", err)
+	}
+
+	tests := []struct {
+		description string
+		pair        *hms_certs.HTTPClientPair
+		expected    bool
+	}{{
+		"No client",
+		nil,
+		false,
+	}, {
+		"No CA URI",
+		insecure,
+		false,
+	}, {
+		"CA bundle",
+		secure,
+		true,
+	}}
+
+	for i, test := range tests {
+		if got := validatesTLS(test.pair); got != test.expected {
+			t.Errorf("Test %v (%s) Failed: Expected %v; Received %v", i, test.description, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// How often MEDS re-reads RedfishEndpoints from HSM, and how long it waits
+// between retries when that fails.  Retries start at hsmSyncRetryBase and
+// double up to hsmSyncMaxBackoff.
+var hsmSyncInterval = 300   // In seconds
+var hsmSyncMaxBackoff = 300 // In seconds
+var hsmSyncRetryBase = 30   // In seconds
+
+// clock is the source of time for the HSM reconciler, so the tests can
+// control it.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// HSMReconciler periodically syncs MEDS' view of endpoint presence with
+// the RedfishEndpoints in HSM.
+type HSMReconciler struct {
+	lock        sync.Mutex
+	lastSuccess time.Time
+	lastError   string
+	failures    int
+
+	interval   time.Duration
+	retryBase  time.Duration
+	maxBackoff time.Duration
+	sync       func(context.Context) error
+	clock      clock
+}
+
+var hsmReconciler *HSMReconciler
+
+func NewHSMReconciler(interval, retryBase, maxBackoff time.Duration, sync func(context.Context) error) *HSMReconciler {
+	return &HSMReconciler{
+		interval:   interval,
+		retryBase:  retryBase,
+		maxBackoff: maxBackoff,
+		sync:       sync,
+		clock:      realClock{},
+	}
+}
+
+// Run syncs with HSM every interval until ctx is cancelled.
+func (r *HSMReconciler) Run(ctx context.Context) {
+	log.Printf("INFO: Starting HSM query thread.")
+
+	wait := r.getInterval()
+	for {
+		select {
+		case <-r.clock.After(wait):
+		case <-ctx.Done():
+			log.Printf("INFO: Quitting HSM monitor thread")
+			return
+		}
+
+		log.Printf("TRACE: Checking up on HSM....")
+		if r.SyncOnce(ctx) != nil {
+			wait = r.backoff()
+		} else {
+			wait = r.getInterval()
+		}
+	}
+}
+
+// SetInterval changes how often the reconciler syncs with HSM, starting
+// after the next sync.
+func (r *HSMReconciler) SetInterval(interval time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	log.Printf("INFO: Syncing with HSM every %d seconds", interval/time.Second)
+	r.interval = interval
+}
+
+func (r *HSMReconciler) getInterval() time.Duration {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.interval
+}
+
+// SyncOnce syncs with HSM and records the outcome.
+func (r *HSMReconciler) SyncOnce(ctx context.Context) error {
+	err := r.sync(ctx)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if err != nil {
+		r.failures++
+		r.lastError = err.Error()
+		log.Printf("WARNING: Sync with HSM failed (%d in a row): %v", r.failures, err)
+	} else {
+		r.failures = 0
+		r.lastError = ""
+		r.lastSuccess = r.clock.Now()
+		hsmLastSyncGauge.Set(float64(r.lastSuccess.Unix()))
+	}
+	medsHealth.setHSMSyncStatus(r.lastSuccess, r.lastError)
+	return err
+}
+
+// backoff returns how long to wait before retrying after the most recent
+// run of failures.
+func (r *HSMReconciler) backoff() time.Duration {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	wait := r.retryBase
+	for i := 1; i < r.failures && wait < r.maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > r.maxBackoff {
+		wait = r.maxBackoff
+	}
+	return wait
+}
+
+// LastSync returns the time of the last successful sync with HSM (zero if
+// there hasn't been one) and the error from the last attempt, if it failed.
+func (r *HSMReconciler) LastSync() (time.Time, string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.lastSuccess, r.lastError
+}
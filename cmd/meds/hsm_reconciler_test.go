@@ -0,0 +1,183 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fakeClock hands every wait the reconciler asks for to the test, which
+// advances the time and releases it.
+type fakeClock struct {
+	lock  sync.Mutex
+	now   time.Time
+	waits chan time.Duration
+	fire  chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{
+		now:   time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		waits: make(chan time.Duration),
+		fire:  make(chan time.Time),
+	}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.waits <- d
+	return c.fire
+}
+
+// nextWait returns the next wait the reconciler asks for.
+func (c *fakeClock) nextWait(t *testing.T) time.Duration {
+	select {
+	case d := <-c.waits:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for the reconciler to wait")
+	}
+	return 0
+}
+
+// elapse advances the time by d and releases the reconciler.
+func (c *fakeClock) elapse(d time.Duration) {
+	c.lock.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+	c.lock.Unlock()
+	c.fire <- now
+}
+
+func Test_HSMReconciler_Run(t *testing.T) {
+	medsHealth = serviceHealth{}
+
+	// The outcome of each sync, and the wait expected after it.
+	syncErr := errors.New("dummy")
+	tests := []struct {
+		description    string
+		err            error
+		expectNextWait time.Duration
+	}{{
+		"Success",
+		nil,
+		300 * time.Second,
+	}, {
+		"Failure",
+		syncErr,
+		30 * time.Second,
+	}, {
+		"Retries back off",
+		syncErr,
+		60 * time.Second,
+	}, {
+		"Retries back off again",
+		syncErr,
+		120 * time.Second,
+	}, {
+		"Backoff is capped",
+		syncErr,
+		200 * time.Second,
+	}, {
+		"Still capped",
+		syncErr,
+		200 * time.Second,
+	}, {
+		"Recovery",
+		nil,
+		300 * time.Second,
+	}}
+
+	syncCount := 0
+	r := NewHSMReconciler(300*time.Second, 30*time.Second, 200*time.Second, func(ctx context.Context) error {
+		err := tests[syncCount].err
+		syncCount++
+		return err
+	})
+	c := newFakeClock()
+	r.clock = c
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx)
+		close(done)
+	}()
+
+	wait := c.nextWait(t)
+	if wait != 300*time.Second {
+		t.Errorf("Expected an initial wait of the sync interval; Received %v", wait)
+	}
+
+	var expectLastSuccess time.Time
+	for i, test := range tests {
+		c.elapse(wait)
+		if test.err == nil {
+			expectLastSuccess = c.Now()
+		}
+
+		// The reconciler asking for its next wait means the sync is done.
+		wait = c.nextWait(t)
+		if wait != test.expectNextWait {
+			t.Errorf("Test %v (%s) Failed: Expected next wait %v; Received %v", i, test.description, test.expectNextWait, wait)
+		}
+		lastSuccess, lastError := r.LastSync()
+		if !lastSuccess.Equal(expectLastSuccess) {
+			t.Errorf("Test %v (%s) Failed: Expected last success at %v; Received %v", i, test.description, expectLastSuccess, lastSuccess)
+		}
+		if (lastError != "") != (test.err != nil) {
+			t.Errorf("Test %v (%s) Failed: Unexpected last error '%s'", i, test.description, lastError)
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Reconciler did not quit")
+	}
+
+	if syncCount != len(tests) {
+		t.Errorf("Expected %d syncs; Received %d", len(tests), syncCount)
+	}
+	status := medsHealth.readiness(c.Now())
+	if !status.Checks[1].Ready {
+		t.Errorf("HSM readiness check not updated by the reconciler: %+v", status.Checks[1])
+	}
+	if testutil.ToFloat64(hsmLastSyncGauge) != float64(expectLastSuccess.Unix()) {
+		t.Errorf("Unexpected last sync metric %v", testutil.ToFloat64(hsmLastSyncGauge))
+	}
+}
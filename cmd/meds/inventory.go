@@ -0,0 +1,159 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	sls_common "github.com/Cray-HPE/hms-sls/v2/pkg/sls-common"
+	"github.com/Cray-HPE/hms-xname/xnametypes"
+)
+
+// If true, only watch the blade BMCs whose slots or BMCs are in SLS.
+var slsInventory = false
+
+// The SLS types that say which positions of a chassis are populated.
+var slsInventoryTypes = []sls_common.HMSStringType{
+	sls_common.ComputeModule,
+	sls_common.NodeBMC,
+	sls_common.RouterBMC,
+}
+
+// ChassisInventory is what SLS knows is in a chassis: the xnames of its
+// compute modules (slots), node BMCs and router BMCs.
+type ChassisInventory struct {
+	Xnames map[string]bool
+}
+
+// hmsParentOfType returns the ancestor of xname with the given type, or ""
+// if it has none.
+func hmsParentOfType(xname string, hmsType xnametypes.HMSType) string {
+	for p := xname; p != ""; p = xnametypes.GetHMSCompParent(p) {
+		if xnametypes.GetHMSType(p) == hmsType {
+			return p
+		}
+	}
+	return ""
+}
+
+// getSLSChassisInventory reads the compute modules, node BMCs and router
+// BMCs in SLS and groups them by chassis.  Chassis with none of them in
+// SLS are not in the result.
+func getSLSChassisInventory(ctx context.Context) (map[string]*ChassisInventory, error) {
+	inventory := make(map[string]*ChassisInventory)
+	for _, hwType := range slsInventoryTypes {
+		hardware, err := getSLSHardwareOfType(ctx, hwType, "")
+		if err != nil {
+			return nil, fmt.Errorf("unable to get %s from SLS: %v", hwType, err)
+		}
+		for _, hw := range hardware {
+			xname := xnametypes.NormalizeHMSCompID(hw.Xname)
+			chassis := hmsParentOfType(xname, xnametypes.Chassis)
+			if chassis == "" {
+				continue
+			}
+			if inventory[chassis] == nil {
+				inventory[chassis] = &ChassisInventory{Xnames: make(map[string]bool)}
+			}
+			inventory[chassis].Xnames[xname] = true
+		}
+	}
+	return inventory, nil
+}
+
+// populated says whether SLS has an endpoint's position in a chassis.  The
+// chassis BMC is always there.  A node BMC is there if SLS has it, or if
+// SLS has its slot but no BMCs for the slot.
+func (inv *ChassisInventory) populated(ne *NetEndpoint) bool {
+	switch ne.hwtype {
+	case TYPE_NODE_CARD:
+		if inv.Xnames[ne.name] {
+			return true
+		}
+		slot := xnametypes.GetHMSCompParent(ne.name)
+		if !inv.Xnames[slot] {
+			return false
+		}
+		for xname := range inv.Xnames {
+			if xnametypes.GetHMSCompParent(xname) == slot {
+				return false
+			}
+		}
+		return true
+	case TYPE_SWITCH_CARD:
+		return inv.Xnames[ne.name]
+	default:
+		return true
+	}
+}
+
+// filter returns the endpoints that SLS has positions for.  A nil
+// inventory keeps every endpoint.
+func (inv *ChassisInventory) filter(endpoints []*NetEndpoint) []*NetEndpoint {
+	if inv == nil {
+		return endpoints
+	}
+	filtered := make([]*NetEndpoint, 0, len(endpoints))
+	for _, ne := range endpoints {
+		if inv.populated(ne) {
+			filtered = append(filtered, ne)
+		}
+	}
+	return filtered
+}
+
+// inventoryChanges works out which endpoints MEDS would generate for an
+// active chassis but isn't tracking, and which it is tracking but would no
+// longer generate.  Must be called with activeEndpointsLock held.
+func inventoryChanges(cabinet, chassis sls_common.GenericHardware, inventory *ChassisInventory) (added, removed []*NetEndpoint) {
+	endpoints, err := chassisEndpoints(cabinet, chassis, inventory)
+	if err != nil {
+		return nil, nil
+	}
+	return diffEndpoints(activeChassis[chassis.Xname], endpoints)
+}
+
+// diffEndpoints compares two lists of endpoints by name, returning those
+// only in 'want' and those only in 'have'.
+func diffEndpoints(have, want []*NetEndpoint) (added, removed []*NetEndpoint) {
+	haveNames := make(map[string]bool, len(have))
+	for _, ne := range have {
+		haveNames[ne.name] = true
+	}
+	wantNames := make(map[string]bool, len(want))
+	for _, ne := range want {
+		wantNames[ne.name] = true
+		if !haveNames[ne.name] {
+			added = append(added, ne)
+		}
+	}
+	for _, ne := range have {
+		if !wantNames[ne.name] {
+			removed = append(removed, ne)
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,167 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/Cray-HPE/hms-certs/pkg/hms_certs"
+	sls_common "github.com/Cray-HPE/hms-sls/v2/pkg/sls-common"
+)
+
+func Test_getSLSChassisInventory(t *testing.T) {
+	hardware := map[string][]sls_common.GenericHardware{
+		"comptype_compmod": {{Xname: "x9000c1s0"}, {Xname: "x9000c1s3"}},
+		"comptype_ncard":   {{Xname: "x9000c1s0b1"}, {Xname: "x9000c3s2b0"}},
+		"comptype_rtr_bmc": {{Xname: "x9000c1r7b0"}},
+	}
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, ok := hardware[r.URL.Query().Get("type")]
+		if r.URL.Path != "/search/hardware" || !ok {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.String())
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(payload)
+	}))
+	defer testServer.Close()
+
+	sls = testServer.URL
+	client, _ = hms_certs.CreateHTTPClientPair("", clientTimeout)
+
+	inventory, err := getSLSChassisInventory(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(inventory) != 2 || inventory["x9000c1"] == nil || inventory["x9000c3"] == nil {
+		t.Fatalf("Expected inventory for x9000c1 and x9000c3; Received %+v", inventory)
+	}
+
+	endpoints, err := chassisEndpoints(testPlanCabinet, testPlanChassis, inventory["x9000c1"])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	names := make([]string, 0, len(endpoints))
+	for _, ne := range endpoints {
+		names = append(names, ne.name)
+	}
+	sort.Strings(names)
+	// s0 only has b1 in SLS, s3 has no BMCs so gets all of them.
+	expected := []string{"x9000c1b0", "x9000c1r7b0", "x9000c1s0b1", "x9000c1s3b0", "x9000c1s3b1"}
+	if len(names) != len(expected) {
+		t.Fatalf("Expected %v; Received %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("Expected %v; Received %v", expected, names)
+			break
+		}
+	}
+}
+
+func Test_ChassisInventory_filter(t *testing.T) {
+	full, err := chassisEndpoints(testPlanCabinet, testPlanChassis, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		description string
+		inventory   *ChassisInventory
+		expectCount int
+	}{{
+		"No slot data in SLS",
+		nil,
+		len(full),
+	}, {
+		"Empty chassis",
+		&ChassisInventory{Xnames: map[string]bool{}},
+		1,
+	}, {
+		"One full blade",
+		&ChassisInventory{Xnames: map[string]bool{"x9000c1s5": true}},
+		3,
+	}, {
+		"Position outside the topology",
+		&ChassisInventory{Xnames: map[string]bool{"x9000c1s5b7": true, "x9000c1r9b0": true}},
+		1,
+	}}
+
+	for i, test := range tests {
+		endpoints := test.inventory.filter(full)
+		if len(endpoints) != test.expectCount {
+			t.Errorf("Test %v (%s) Failed: Expected %d endpoints; Received %d",
+				i, test.description, test.expectCount, len(endpoints))
+		}
+		if added, removed := diffEndpoints(endpoints, test.inventory.filter(full)); len(added)+len(removed) > 0 {
+			t.Errorf("Test %v (%s) Failed: Filtering is not repeatable", i, test.description)
+		}
+	}
+}
+
+func Test_update_chassis(t *testing.T) {
+	defer func() { probeScheduler = nil }()
+	probeScheduler = NewProbeScheduler(1, 0, nil, nil, nil)
+
+	full, err := chassisEndpoints(testPlanCabinet, testPlanChassis, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	activeEndpoints = make(map[string]*NetEndpoint)
+	activeChassis = make(map[string][]*NetEndpoint)
+	startWatching(testPlanChassis.Xname, testPlanCabinet.Xname, full)
+	kept := activeEndpoints["x9000c1s5b0"]
+
+	// SLS loses everything but the chassis BMC and one blade.
+	inventory := &ChassisInventory{Xnames: map[string]bool{"x9000c1s5": true}}
+	added, removed := inventoryChanges(testPlanCabinet, testPlanChassis, inventory)
+	if len(added) != 0 || len(removed) != len(full)-3 {
+		t.Fatalf("Expected 0 endpoints added and %d removed; Received %d and %d", len(full)-3, len(added), len(removed))
+	}
+	err = update_chassis(context.Background(), testPlanCabinet, testPlanChassis, added, removed)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(activeChassis[testPlanChassis.Xname]) != 3 || len(activeEndpoints) != 3 {
+		t.Errorf("Expected 3 endpoints left; Received %d in the chassis and %d active",
+			len(activeChassis[testPlanChassis.Xname]), len(activeEndpoints))
+	}
+	if activeEndpoints["x9000c1s5b0"] != kept {
+		t.Errorf("Endpoint still in SLS was replaced")
+	}
+	if _, ok := activeEndpoints["x9000c1s4b0"]; ok {
+		t.Errorf("Endpoint no longer in SLS is still active")
+	}
+	if added, removed := inventoryChanges(testPlanCabinet, testPlanChassis, inventory); len(added)+len(removed) > 0 {
+		t.Errorf("Expected no further changes; Received %d added and %d removed", len(added), len(removed))
+	}
+}
@@ -28,28 +28,29 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
-	"math/rand"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	base "github.com/Cray-HPE/hms-base/v2"
 	dns_dhcp "github.com/Cray-HPE/hms-dns-dhcp/pkg"
 	sls_common "github.com/Cray-HPE/hms-sls/v2/pkg/sls-common"
 	"github.com/Cray-HPE/hms-smd/v2/pkg/sm"
-	"github.com/Cray-HPE/hms-xname/xnames"
-	"github.com/Cray-HPE/hms-xname/xnametypes"
 
+	"github.com/Cray-HPE/hms-meds/internal/macaddr"
 	"github.com/Cray-HPE/hms-meds/internal/model"
 
 	compcreds "github.com/Cray-HPE/hms-compcredentials"
@@ -64,7 +65,10 @@ import (
 //
 // At the top level, each cabinet has 2 Environmental controllers (eC) and
 // 8 chassis (each of which has a chassis controller (cC)). Each chassis
-// then has eight slots, each slot contains 2 node cards (nC)
+// then has eight slots, each slot contains 2 node cards (nC), and eight
+// switch cards (sC).  Which slots, node cards and switch cards a chassis
+// actually has is described by its Topology; these constants describe the
+// built-in ones.
 
 // MTN_eC_COUNT is the number of Environmental Controllers per rack.
 const MTN_eC_COUNT = 2
@@ -72,13 +76,16 @@ const MTN_eC_COUNT = 2
 // MTN_CHASSIS_COUNT is the number of chasses per rack.
 const MTN_CHASSIS_COUNT = 8
 
+// MTN_SLOT_COUNT is the number of compute slots in a chassis.
+const MTN_SLOT_COUNT = 8
+
 // MTN_SWITCH_COUNT is the number of switches in a chassis
 const MTN_SWITCH_COUNT = 8
 
 // MTN_nC_PER_SLOT is the number of node cards in each slot.
 const MTN_nC_PER_SLOT = 2
 
-const MAC_PREFIX = "02"
+const MAC_PREFIX = macaddr.DefaultPrefix
 
 type HSMNotification struct {
 	ID                 string `json:"ID"`
@@ -88,6 +95,7 @@ type HSMNotification struct {
 	User               string `json:"User,omitempty"`
 	Password           string `json:"Password,omitempty"`
 	MACAddr            string `json:"MACAddr,omitempty"`
+	UUID               string `json:"UUID,omitempty"`
 	RediscoverOnUpdate bool   `json:"RediscoverOnUpdate,omitempty"`
 	Enabled            *bool  `json:"Enabled,omitempty"` //need to set a default
 }
@@ -99,10 +107,51 @@ type HSMNotificationArray struct {
 type NetEndpoint struct {
 	name        string
 	mac         string
+	address     string // Probed instead of name if set
 	hwtype      int
 	HSMPresence HSMEndpointPresence
 	HSMPresLock sync.Mutex
-	QuitChannel chan struct{}
+
+	// Results of the most recent Redfish ping and the time of the most
+	// recent HSMPresence change.  Protected by HSMPresLock.
+	lastPing         time.Time
+	lastPingPresence HSMEndpointPresence
+	lastPingErr      string
+	lastTransition   time.Time
+
+	// When the endpoint was first found to be unreachable while present
+	// in HSM, and whether MEDS has since marked it absent without telling
+	// HSM (see absentPolicy).  Protected by HSMPresLock.
+	missingSince  time.Time
+	locallyAbsent bool
+
+	// The service root UUID of the BMC MEDS last initialized or saw for
+	// this endpoint, or else the UUID HSM discovered for it.  Only the UUID
+	// is used, not the BMC's serial number or MAC address.  Protected by
+	// HSMPresLock.
+	fingerprint string
+
+	// What MEDS last found out about the endpoint: its service root, its
+	// Vault credentials, its NetworkProtocol compliance and how its last
+	// initialization went.  Each is the zero value until first found out.
+	// Protected by HSMPresLock.
+	serviceRoot     ServiceRootInfo
+	credentialCheck CredentialCheck
+	compliance      EndpointCompliance
+	initStatus      InitStatus
+}
+
+// setHSMPresence updates the HSM presence of an endpoint, recording the
+// time of the transition if the presence actually changed.  The caller
+// must hold ne.HSMPresLock.
+func (ne *NetEndpoint) setHSMPresence(presence HSMEndpointPresence) {
+	if ne.HSMPresence != presence {
+		ne.lastTransition = time.Now()
+	}
+	ne.HSMPresence = presence
+	if presence == PRESENCE_PRESENT {
+		ne.locallyAbsent = false
+	}
 }
 
 type EndpointType int
@@ -130,6 +179,14 @@ var EndpointTypeToString map[int]string = map[int]string{
 	TYPE_CHASSIS:        "Chassis",
 }
 
+// What MEDS does when an endpoint that is present in HSM stops answering
+// Redfish pings for at least absentGrace seconds.
+const (
+	ABSENT_POLICY_IGNORE  = "ignore"  // Nothing; the endpoint stays present
+	ABSENT_POLICY_DISABLE = "disable" // Disable the RedfishEndpoint in HSM
+	ABSENT_POLICY_ABSENT  = "absent"  // Mark the endpoint absent in MEDS only
+)
+
 var HSMEndpointPresenceToString map[HSMEndpointPresence]string = map[HSMEndpointPresence]string{
 	PRESENCE_PRESENT:     "present",
 	PRESENCE_NOT_PRESENT: "not present",
@@ -144,6 +201,9 @@ var defSSHKey string
 var hms_ca_uri string
 var clientTimeout = 5
 var maxInitialHSMSyncAttempts int
+var httpListen string
+var absentPolicy = ABSENT_POLICY_IGNORE
+var absentGrace = 0 // In seconds
 
 // The HSM Credentials store
 var hcs *compcreds.CompCredStore
@@ -170,7 +230,8 @@ var startupVariableWaitMax = checkupFixedWait // In seconds - the maximum each c
 var credStorage *model.MedsCredStore
 
 // Variables for tracking what's around/available
-// List of net endpoints by the chassis they belong to
+// List of net endpoints by the chassis they belong to.  CECs are listed
+// under their cabinet.
 // We'll need this for now, because we have to add/remove endpoints based on chassis presence
 var activeChassis map[string][]*NetEndpoint = make(map[string][]*NetEndpoint)
 
@@ -184,28 +245,6 @@ var hsmRedfishEndpointsCacheLock sync.Mutex
 // Lock for modifying the above two structures
 var activeEndpointsLock sync.Mutex
 
-//
-// MAC Address generation functions
-//
-
-func GenerateMAC(mp string, rack int, chassis int, slt int, idx int) string {
-	return fmt.Sprintf("%s:%02X:%02X:%02X:%02X:%02X", mp,
-		(rack>>8)&0xFF, rack&0xFF, chassis&0xFF, slt&0xFF, (idx<<4)&0xFF)
-}
-
-func GenerateMACnC(mp string, rack int, chassis int, slt int, idx int) string {
-	return GenerateMAC(mp, rack, chassis, slt+48, idx)
-}
-
-func GenerateMACsC(mp string, rack int, chassis int, slt int) string {
-	return GenerateMAC(mp, rack, chassis, slt+96, 0)
-
-}
-
-func GenerateMACcC(mp string, rack int, chassis int) string {
-	return GenerateMAC(mp, rack, chassis, 0, 0)
-}
-
 // GenerateEnvironmentalControllerEndpoints generates the Environmental
 //  Controller (eC) entries for a given rack.
 // Parameters:
@@ -229,13 +268,13 @@ func GenerateEnvironmentalControllerEndpoints(rack int) []*NetEndpoint {
 }
 
 // GenerateNodeCardEndpoints builds the Node Card (nC) entries for a specific slot.
-func GenerateNodeCardEndpoints(macprefix string, rack int, chassis int, slot int) []*NetEndpoint {
+func GenerateNodeCardEndpoints(topology *Topology, scheme macaddr.MACScheme, macprefix string, rack int, chassis int, slot int) []*NetEndpoint {
 	ret := make([]*NetEndpoint, 0)
 
-	for nc := 0; nc < MTN_nC_PER_SLOT; nc++ {
+	for _, nc := range topology.NodeBMCs {
 		ep := new(NetEndpoint)
 		ep.name = fmt.Sprintf("x%dc%ds%db%d", rack, chassis, slot, nc)
-		ep.mac = GenerateMACnC(macprefix, rack, chassis, slot, nc)
+		ep.mac = scheme.NodeController(macprefix, rack, chassis, slot, nc)
 		ep.hwtype = TYPE_NODE_CARD
 		ep.HSMPresence = PRESENCE_NOT_PRESENT
 		ret = append(ret, ep)
@@ -243,47 +282,50 @@ func GenerateNodeCardEndpoints(macprefix string, rack int, chassis int, slot int
 	return ret
 }
 
-func GenerateSwitchCardEndpoints(macprefix string, rack int, chassis int) []*NetEndpoint {
+// GenerateSwitchCardEndpoints builds the Switch Card (sC) entries for a chassis.
+func GenerateSwitchCardEndpoints(topology *Topology, scheme macaddr.MACScheme, macprefix string, rack int, chassis int) []*NetEndpoint {
 	endpoints := make([]*NetEndpoint, 0)
 
-	for card := 0; card < MTN_SWITCH_COUNT; card++ {
-
+	for _, card := range topology.SwitchCards {
 		ep := new(NetEndpoint)
 		ep.name = fmt.Sprintf("x%dc%dr%db0", rack, chassis, card)
-		ep.mac = GenerateMACsC(macprefix, rack, chassis, card)
+		ep.mac = scheme.SwitchController(macprefix, rack, chassis, card)
 		ep.hwtype = TYPE_SWITCH_CARD
 		ep.HSMPresence = PRESENCE_NOT_PRESENT
 		endpoints = append(endpoints, ep)
-		// Use "variadic slice append" notation. Go figure...
-		// (This presents the slice to be appended as a list of
-		// variadic arguments to the append function)
-		endpoints = append(endpoints, GenerateNodeCardEndpoints(
-			macprefix, rack, chassis, card)...)
 	}
 
 	return endpoints
 }
 
-//
-func GenerateChassisEndpoints(macprefix string, rack int, chassisList []int) []*NetEndpoint {
+// GenerateChassisEndpoints builds the chassis (cC), switch card and node
+// card entries for each of the chassis in a rack.
+func GenerateChassisEndpoints(topology *Topology, scheme macaddr.MACScheme, macprefix string, rack int, chassisList []int) []*NetEndpoint {
 	endpoints := make([]*NetEndpoint, 0)
 
 	for _, chassis := range chassisList {
 		cc := new(NetEndpoint)
 		cc.name = fmt.Sprintf("x%dc%db0", rack, chassis)
-		cc.mac = GenerateMACcC(macprefix, rack, chassis)
+		cc.mac = scheme.ChassisController(macprefix, rack, chassis)
 		cc.hwtype = TYPE_CHASSIS
 		cc.HSMPresence = PRESENCE_NOT_PRESENT
 		endpoints = append(endpoints, cc)
 
+		// Use "variadic slice append" notation. Go figure...
+		// (This presents the slice to be appended as a list of
+		// variadic arguments to the append function)
 		endpoints = append(endpoints, GenerateSwitchCardEndpoints(
-			macprefix, rack, chassis)...)
+			topology, scheme, macprefix, rack, chassis)...)
+		for _, slot := range topology.Slots {
+			endpoints = append(endpoints, GenerateNodeCardEndpoints(
+				topology, scheme, macprefix, rack, chassis, slot)...)
+		}
 	}
 
 	return endpoints
 }
 
-func patchXNameEnabled(xname string, enabled bool) *error {
+func patchXNameEnabled(ctx context.Context, xname string, enabled bool) *error {
 	var strbody []byte
 
 	payload := HSMNotification{
@@ -302,11 +344,16 @@ func patchXNameEnabled(xname string, enabled bool) *error {
 
 	log.Printf("DEBUG: PATCH to %s/Inventory/RedfishEndpoints/%s", hsm, xname)
 
-	req, err := http.NewRequest(http.MethodPatch, hsm+"/Inventory/RedfishEndpoints/"+xname, bytes.NewReader(rawPayload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, hsm+"/Inventory/RedfishEndpoints/"+xname, bytes.NewReader(rawPayload))
+	if err != nil {
+		log.Printf("WARNING: Unable to create HTTP request for %s: %v", xname, err)
+		return &err
+	}
 	req.Header.Add("Content-Type", "application/json")
 	base.SetHTTPUserAgent(req, serviceName)
 	resp, err := client.Do(req)
 	defer base.DrainAndCloseResponseBody(resp)
+	recordRequest("hsm", "patch_redfish_endpoint", resp, err)
 	if err != nil {
 		log.Printf("WARNING: Unable to patch %s: %v", xname, err)
 		return &err
@@ -326,7 +373,7 @@ func patchXNameEnabled(xname string, enabled bool) *error {
 	return nil
 }
 
-func patchXnameFQDN(xname, fqdn, hostname string) error {
+func patchXnameFQDN(ctx context.Context, xname, fqdn, hostname string) error {
 	var strbody []byte
 
 	payload := HSMNotification{
@@ -343,11 +390,16 @@ func patchXnameFQDN(xname, fqdn, hostname string) error {
 
 	log.Printf("DEBUG: PATCH to %s/Inventory/RedfishEndpoints/%s", hsm, xname)
 
-	req, err := http.NewRequest(http.MethodPatch, hsm+"/Inventory/RedfishEndpoints/"+xname, bytes.NewReader(rawPayload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, hsm+"/Inventory/RedfishEndpoints/"+xname, bytes.NewReader(rawPayload))
+	if err != nil {
+		log.Printf("WARNING: Unable to create HTTP request for %s: %v", xname, err)
+		return err
+	}
 	req.Header.Add("Content-Type", "application/json")
 	base.SetHTTPUserAgent(req, serviceName)
 	resp, err := client.Do(req)
 	defer base.DrainAndCloseResponseBody(resp)
+	recordRequest("hsm", "patch_redfish_endpoint", resp, err)
 	if err != nil {
 		log.Printf("WARNING: Unable to patch %s: %v", xname, err)
 		return err
@@ -367,58 +419,162 @@ func patchXnameFQDN(xname, fqdn, hostname string) error {
 	return nil
 }
 
-func notifyXnamePresent(node NetEndpoint, address string) *error {
-	perNodeCred, err := hcs.GetCompCred(node.name)
-	if err != nil {
-		log.Printf("WARNING: Unable to retrieve key %s from vault: %s", node.name, err)
-		return &err
-	}
+func notifyXnamePresent(ctx context.Context, node *NetEndpoint, address string) *error {
+	p := newInitPipeline(node)
 
-	// If we get nothing back from Vault then we need to push something in.
-	if perNodeCred.Username == "" || perNodeCred.Password == "" {
-		// Grab the global credentails
-		globalCreds, err := credStorage.FindGlobalCredentials()
-		if err != nil || len(globalCreds.Username) == 0 {
-			if len(defUser) != 0 {
-				log.Printf("WARNING: Unable to retrieve MEDS global credentials (err: %s) or retrieved credentials are "+
-					"empty, using defaults", err)
-				globalCreds = model.MedsCredentials{
-					Username: defUser,
-					Password: defPass,
-				}
-			} else {
-				err = fmt.Errorf("Unable to retrieve MEDS global credentials (err: %s) or retrieved credentials are "+
-					"empty. No defaults are set, so refusing to continue adding Xname", err)
-				log.Printf("WARNING: %s", err)
-				return &err
-			}
+	// HSM pulls the credentials from Vault, so nothing else is done until
+	// they are stored there.
+	var perNodeCred compcreds.CompCredentials
+	err := p.run(STEP_CREDENTIALS, true, func() error {
+		var err error
+		perNodeCred, p.status.CredentialSource, err = seedCredentials(node.name)
+		return err
+	})
+	if err != nil {
+		log.Printf("WARNING: Not registering %s as its credentials could not be stored: %v", node.name, err)
+		if node.hwtype != TYPE_ENV_CONTROLLER {
+			p.skip(STEP_GEOLOCATION, STEP_NETWORK_PROTOCOL)
 		}
+		p.skip(STEP_HSM)
+		return p.finish()
+	}
 
-		// Push in the default creds into vault
-		perNodeCred.Xname = node.name
-		perNodeCred.Username = globalCreds.Username
-		perNodeCred.Password = globalCreds.Password
+	// CECs only need registering; they have none of the BMC settings
+	// below.
+	if node.hwtype == TYPE_ENV_CONTROLLER {
+		p.run(STEP_HSM, true, func() error {
+			return derefError(notifyHSMXnamePresent(ctx, *node, address))
+		})
+		return p.finish()
+	}
 
-		log.Printf("INFO: No creds exist for %s in vault, setting it to the MEDS global defaults", node.name)
+	// HSM will use these credentials, so make sure they work.
+	perNodeCred = verifyCredentials(ctx, node, address, perNodeCred)
 
-		err = hcs.StoreCompCred(perNodeCred)
+	// Make sure this is the BMC we think it is before configuring it.
+	if geolocationCheck == GEOLOCATION_OFF {
+		p.skip(STEP_GEOLOCATION)
+	} else {
+		err = p.run(STEP_GEOLOCATION, false, func() error {
+			return verifyGeolocation(ctx, node, address, perNodeCred.Username, perNodeCred.Password)
+		})
 		if err != nil {
-			// If we fail to store credentials in vault, we'll lose the
-			// credentials and the component endpoints associated with
-			// them will still be successfully in the database.
-			log.Printf("Failed to store credentials for %s in Vault - %s", node.name, err)
+			p.skip(STEP_NETWORK_PROTOCOL, STEP_HSM)
+			return p.finish()
 		}
 	}
 
-	bmcCreds, err := credStorage.FindBMCSSHCredentials(node.name)
+	// Once HSM has the BMC nothing sets its NetworkProtocol again, so it is
+	// only registered once NTP, syslog and SSH are set.
+	tmpBMCCreds, sshSource := desiredNetworkProtocol(node.name)
+	p.status.SSHCredentialSource = sshSource
+	log.Printf("DEBUG: Using SSH credentials for %s from %s", node.name, sshSource)
+	err = p.run(STEP_NETWORK_PROTOCOL, true, func() error {
+		rfClientLock.RLock()
+		nstError := bmc_nwprotocol.SetXNameNWPInfo(tmpBMCCreds, address, perNodeCred.Username, perNodeCred.Password)
+		rfClientLock.RUnlock()
+		recordResult("redfish", "patch_network_protocol", nstError)
+		return nstError
+	})
+	if err != nil {
+		log.Printf("WARNING: Not registering %s as its NetworkProtocol could not be set: %v", node.name, err)
+		p.skip(STEP_HSM)
+		return p.finish()
+	}
+
+	p.run(STEP_HSM, true, func() error {
+		return derefError(notifyHSMXnamePresent(ctx, *node, address))
+	})
+	return p.finish()
+}
+
+// derefError turns the *error the HSM notifiers return into an error.
+func derefError(err *error) error {
+	if err == nil {
+		return nil
+	}
+	return *err
+}
+
+// seedCredentials returns an endpoint's credentials from Vault, first
+// storing the credentials MEDS resolves for it there if it has none.  The
+// Vault key the credentials came from is returned with them.
+func seedCredentials(xname string) (compcreds.CompCredentials, string, error) {
+	source := hcs.CCPath + "/" + xname
+	perNodeCred, err := hcs.GetCompCred(xname)
+	recordResult("vault", "get_comp_cred", err)
+	if err != nil {
+		log.Printf("WARNING: Unable to retrieve key %s from vault: %s", xname, err)
+		return perNodeCred, source, err
+	}
+	if perNodeCred.Username != "" && perNodeCred.Password != "" {
+		return perNodeCred, source, nil
+	}
+
+	// If we get nothing back from Vault then we need to push something in.
+	resolvedCreds, source, err := resolveCredentials(xname)
+	if err != nil {
+		return perNodeCred, source, err
+	}
+
+	perNodeCred.Xname = xname
+	perNodeCred.Username = resolvedCreds.Username
+	perNodeCred.Password = resolvedCreds.Password
+
+	log.Printf("INFO: No creds exist for %s in vault, setting it to the MEDS credentials from %s", xname, source)
+
+	err = hcs.StoreCompCred(perNodeCred)
+	recordResult("vault", "store_comp_cred", err)
+	if err != nil {
+		// HSM would be unable to authenticate to the endpoint without
+		// them.
+		log.Printf("WARNING: Failed to store credentials for %s in Vault - %s", xname, err)
+		return perNodeCred, source, fmt.Errorf("unable to store credentials in Vault: %v", err)
+	}
+	return perNodeCred, source, nil
+}
+
+// The credential source recorded when MEDS falls back to its defaults.
+const CREDENTIAL_SOURCE_DEFAULTS = "defaults"
+
+// resolveCredentials returns the MEDS BMC credentials for an xname: the
+// most specific of those in Vault for the xname, its parents up to its
+// cabinet, and the global ones, or the defaults if there are none.  The
+// Vault key they came from is returned with them.
+func resolveCredentials(xname string) (model.MedsCredentials, string, error) {
+	creds, source, err := credStorage.ResolveBMCCredentials(xname)
+	recordResult("vault", "resolve_creds", err)
+	if err == nil && len(creds.Username) != 0 {
+		return creds, source, nil
+	}
+	if len(defUser) == 0 {
+		return creds, source, fmt.Errorf("Unable to retrieve MEDS credentials for %s (err: %s) or retrieved credentials are "+
+			"empty. No defaults are set", xname, err)
+	}
+	log.Printf("WARNING: Unable to retrieve MEDS credentials for %s (err: %s) or retrieved credentials are "+
+		"empty, using defaults", xname, err)
+	return model.MedsCredentials{
+		Username: defUser,
+		Password: defPass,
+	}, CREDENTIAL_SOURCE_DEFAULTS, nil
+}
+
+// desiredNetworkProtocol is the NetworkProtocol MEDS pushes to a BMC: the
+// NTP and syslog settings for every BMC plus the BMC's SSH keys, resolved
+// the same way as its credentials.  The Vault key the SSH keys came from is
+// returned with it.
+func desiredNetworkProtocol(xname string) (bmc_nwprotocol.RedfishNWProtocol, string) {
+	bmcCreds, source, err := credStorage.ResolveBMCSSHCredentials(xname)
+	recordResult("vault", "get_ssh_creds", err)
 	if err != nil || len(bmcCreds.Username) == 0 {
-		log.Printf("WARNING: Unable to retrieve MEDS SSH credentials (err: %s) or retrieved credentials are "+
-			"empty, using defaults", err)
+		log.Printf("WARNING: Unable to retrieve MEDS SSH credentials for %s (err: %s) or retrieved credentials are "+
+			"empty, using defaults", xname, err)
 		bmcCreds = model.MedsSSHCredentials{
 			Username:      defUser,
 			Password:      defPass,
 			AuthorizedKey: defSSHKey,
 		}
+		source = CREDENTIAL_SOURCE_DEFAULTS
 	}
 
 	tmpBMCCreds := bmc_nwprotocol.CopyRFNetworkProtocol(&rfNWPStatic)
@@ -432,27 +588,21 @@ func notifyXnamePresent(node NetEndpoint, address string) *error {
 		tmpBMCCreds.Oem.SSHAdmin = nil
 		tmpBMCCreds.Oem.SSHConsole = nil
 	}
-
-	rfClientLock.RLock()
-	nstError := bmc_nwprotocol.SetXNameNWPInfo(tmpBMCCreds, address, perNodeCred.Username, perNodeCred.Password)
-	rfClientLock.RUnlock()
-
-	hsmError := notifyHSMXnamePresent(node, address)
-
-	if (hsmError != nil) || (nstError != nil) {
-		finalError := fmt.Errorf("%v %v", hsmError, nstError)
-		return &finalError
-	}
-	return nil
+	return tmpBMCCreds, source
 }
 
-func notifyHSMXnamePresent(node NetEndpoint, address string) *error {
+func notifyHSMXnamePresent(ctx context.Context, node NetEndpoint, address string) *error {
 	var strbody []byte
 
+	fqdn := node.name
+	if node.address != "" {
+		fqdn = node.address
+	}
+
 	// No longer include User and Password (set to blank) to signal HSM to pull from Vault
 	payload := HSMNotification{
 		ID:                 node.name,
-		FQDN:               node.name,
+		FQDN:               fqdn,
 		User:               "", // blank to pull from Vault
 		Password:           "", // blank to pull from Vault
 		MACAddr:            node.mac,
@@ -468,7 +618,7 @@ func notifyHSMXnamePresent(node NetEndpoint, address string) *error {
 	log.Printf("DEBUG: POST to %s/Inventory/RedfishEndpoints with %s", hsm, string(rawPayload))
 
 	url := hsm + "/Inventory/RedfishEndpoints"
-	req, qerr := http.NewRequest(http.MethodPost, url, bytes.NewReader(rawPayload))
+	req, qerr := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(rawPayload))
 	if qerr != nil {
 		log.Printf("WARNING: Unable to create HTTP request for %s: %v",
 			node.name, qerr)
@@ -478,6 +628,7 @@ func notifyHSMXnamePresent(node NetEndpoint, address string) *error {
 	base.SetHTTPUserAgent(req, serviceName)
 	resp, err := client.Do(req)
 	defer base.DrainAndCloseResponseBody(resp)
+	recordRequest("hsm", "post_redfish_endpoint", resp, err)
 	if err != nil {
 		log.Printf("WARNING: Unable to send information for %s: %v", node.name, err)
 		return &err
@@ -491,7 +642,7 @@ func notifyHSMXnamePresent(node NetEndpoint, address string) *error {
 		log.Printf("INFO: Successfully created %s", node.name)
 	} else if resp.StatusCode == 409 {
 		log.Printf("INFO: %s alredy present; patching instead", node.name)
-		return patchXNameEnabled(node.name, true)
+		return patchXNameEnabled(ctx, node.name, true)
 	} else {
 		log.Printf("WARNING: An error occurred uploading %s: %s %v", node.name, resp.Status, string(strbody))
 		rerr := errors.New("Unable to upload information for " + node.name + " to HSM: " + fmt.Sprint(resp.StatusCode) + "\n" + string(strbody))
@@ -501,47 +652,96 @@ func notifyHSMXnamePresent(node NetEndpoint, address string) *error {
 	return nil
 }
 
-func notifyHSMXnameNotPresent(node NetEndpoint) *error {
-	log.Printf("DEBUG: Would remove %s, but MEDS no longer marks redfishEndpoints as disabled. This message is purely for your information; MEDS is operating as expected.", node.name)
+func notifyHSMXnameNotPresent(ctx context.Context, node NetEndpoint) *error {
+	switch absentPolicy {
+	case ABSENT_POLICY_DISABLE:
+		log.Printf("INFO: Disabling %s in HSM as it is no longer reachable", node.name)
+		return patchXNameEnabled(ctx, node.name, false)
+	case ABSENT_POLICY_ABSENT:
+		log.Printf("INFO: Marking %s absent; it will be re-initialized when it reappears", node.name)
+	default:
+		log.Printf("DEBUG: Would remove %s, but MEDS is configured to leave redfishEndpoints alone (absent policy '%s'). This message is purely for your information; MEDS is operating as expected.", node.name, absentPolicy)
+	}
 
 	return nil
 }
 
-func queryHSMState() error {
-	endpoints := activeEndpoints
-	// Lock the presence field for all endpoints so other
-	// functions that might modify this field won't.
-	for _, ep := range endpoints {
-		ep.HSMPresLock.Lock()
-		defer ep.HSMPresLock.Unlock()
+// validAbsentPolicy checks an absent policy name.
+func validAbsentPolicy(policy string) bool {
+	switch policy {
+	case ABSENT_POLICY_IGNORE, ABSENT_POLICY_DISABLE, ABSENT_POLICY_ABSENT:
+		return true
 	}
+	return false
+}
 
+// applyHSMRedfishEndpoint updates an endpoint's HSMPresence from its
+// RedfishEndpoint in HSM.  The caller must hold ep.HSMPresLock.
+func applyHSMRedfishEndpoint(ep *NetEndpoint, rfEP HSMNotification, found bool) {
+	if found && ep.fingerprint == "" {
+		// Until MEDS sees the BMC itself, the UUID HSM found is the one
+		// to compare against.
+		ep.fingerprint = rfEP.UUID
+	}
+	if !found {
+		// Redfish Endpoint was in the HSM inventory, but no longer present. ie Deleted
+		if ep.HSMPresence != PRESENCE_NOT_PRESENT {
+			log.Printf("DEBUG: %s is now not present in HSM", ep.name)
+		}
+		ep.setHSMPresence(PRESENCE_NOT_PRESENT)
+	} else if rfEP.Enabled != nil && *(rfEP.Enabled) != true {
+		// Redfish endpoint is present in HSM inventory, but has been manually marked disabled
+		// MEDS treats this as if the ENDPOINT is not present/
+		// present and set false
+		if ep.HSMPresence != PRESENCE_NOT_PRESENT {
+			log.Printf("DEBUG: %s is now not present in HSM", ep.name)
+		}
+		ep.setHSMPresence(PRESENCE_NOT_PRESENT)
+	} else {
+		// Redfish endpoint is present within HSM inventory and enabled
+		// present and set true OR flag not present
+		if ep.locallyAbsent {
+			// MEDS has marked it absent itself; keep it that way until
+			// it reappears and is re-initialized.
+			return
+		}
+		if ep.HSMPresence != PRESENCE_PRESENT {
+			log.Printf("DEBUG: %s is now present in HSM", ep.name)
+		}
+		ep.setHSMPresence(PRESENCE_PRESENT)
+	}
+}
+
+// getHSMRedfishEndpoints reads every RedfishEndpoint from HSM, keyed by
+// xname.
+func getHSMRedfishEndpoints(ctx context.Context) (map[string]HSMNotification, error) {
 	log.Printf("DEBUG: GET from %s/Inventory/RedfishEndpoints", hsm)
 
 	url := hsm + "/Inventory/RedfishEndpoints"
-	req, qerr := http.NewRequest(http.MethodGet, url, nil)
+	req, qerr := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if qerr != nil {
 		log.Printf("WARNING: Unable to create HTTP request for HSM query: %v",
 			qerr)
-		return qerr
+		return nil, qerr
 	}
 	resp, err := client.Do(req)
 	defer base.DrainAndCloseResponseBody(resp)
+	recordRequest("hsm", "get_redfish_endpoints", resp, err)
 	if err != nil {
 		log.Printf("WARNING: Unable to get RedfishEndpoints from HSM: %v", err)
-		return err
+		return nil, err
 	}
 
 	if resp.Body == nil {
 		emsg := fmt.Errorf("No response body from querying HSM for RedfishEndpoints.")
 		log.Printf("WARNING: %v", emsg)
-		return emsg
+		return nil, emsg
 	}
 
 	bodyBytes, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("WARNING: Unable to read HTTP body while querying HSM for RedfishEndpoints: %v", err)
-		return err
+		return nil, err
 	}
 
 	if resp.StatusCode == 200 {
@@ -549,61 +749,64 @@ func queryHSMState() error {
 		err := json.Unmarshal(bodyBytes, rfEPs)
 		if err != nil {
 			log.Printf("WARNING: Unable to unmarshal HSM data: %v", err)
-			return err
+			return nil, err
 		}
 		rfEPMap := make(map[string]HSMNotification, 0)
 		for _, rfEP := range rfEPs.RedfishEndpoints {
 			rfEPMap[rfEP.ID] = rfEP
 		}
-		for _, ep := range endpoints {
-			rfEP, ok := rfEPMap[ep.name]
-			if !ok {
-				// Redfish Endpoint was in the HSM inventory, but no longer present. ie Deleted
-				if ep.HSMPresence != PRESENCE_NOT_PRESENT {
-					log.Printf("DEBUG: %s is now not present in HSM", ep.name)
-				}
-				ep.HSMPresence = PRESENCE_NOT_PRESENT
-			} else if rfEP.Enabled != nil && *(rfEP.Enabled) != true {
-				// Redfish endpoint is present in HSM inventory, but has been manually marked disabled
-				// MEDS treats this as if the ENDPOINT is not present/
-				// present and set false
-				if ep.HSMPresence != PRESENCE_NOT_PRESENT {
-					log.Printf("DEBUG: %s is now not present in HSM", ep.name)
-				}
-				ep.HSMPresence = PRESENCE_NOT_PRESENT
-			} else {
-				// Redfish endpoint is present within HSM inventory and enabled
-				// present and set true OR flag not present
-				if ep.HSMPresence != PRESENCE_PRESENT {
-					log.Printf("DEBUG: %s is now present in HSM", ep.name)
-				}
-				ep.HSMPresence = PRESENCE_PRESENT
-			}
-		}
-
-		// Update HSM Redfish endpoint cache
-		hsmRedfishEndpointsCacheLock.Lock()
-		hsmRedfishEndpointsCache = rfEPMap
-		hsmRedfishEndpointsCacheLock.Unlock()
-
-		return nil
+		return rfEPMap, nil
 	}
 
 	// else ...
 	log.Printf("WARNING: Error occurred looking up RedfishEndpoints in HSM (code %d):\n%s", resp.StatusCode, string(bodyBytes))
 	rerr := errors.New("Unable to retrieve status from HSM: " + fmt.Sprint(resp.StatusCode) + "\n" + string(bodyBytes))
-	return rerr
+	return nil, rerr
 }
 
-func queryNetworkStatusViaAddress(address string) (HSMEndpointPresence, *error) {
+func queryHSMState(ctx context.Context) error {
+	endpoints := activeEndpoints
+	// Lock the presence field for all endpoints so other
+	// functions that might modify this field won't.
+	for _, ep := range endpoints {
+		ep.HSMPresLock.Lock()
+		defer ep.HSMPresLock.Unlock()
+	}
+
+	rfEPMap, err := getHSMRedfishEndpoints(ctx)
+	if err != nil {
+		return err
+	}
+	for _, ep := range endpoints {
+		rfEP, ok := rfEPMap[ep.name]
+		applyHSMRedfishEndpoint(ep, rfEP, ok)
+	}
+
+	// Update HSM Redfish endpoint cache
+	hsmRedfishEndpointsCacheLock.Lock()
+	hsmRedfishEndpointsCache = rfEPMap
+	hsmRedfishEndpointsCacheLock.Unlock()
+
+	return nil
+}
+
+// queryNetworkStatusViaAddress Redfish pings an address, classifying what
+// answers (see classifyServiceRoot).
+func queryNetworkStatusViaAddress(ctx context.Context, address string) (string, RedfishServiceRoot, *error) {
 	//Redfish operation; try validated HTTP first, then fail over to un-validated.
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+address+"/redfish/v1/", nil)
+	if err != nil {
+		return PROBE_ERROR, RedfishServiceRoot{}, &err
+	}
 	rfClientLock.RLock()
-	resp, err := rfClient.Get("https://" + address + "/redfish/v1/")
+	resp, err := rfClient.Do(req)
 	rfClientLock.RUnlock()
 	defer base.DrainAndCloseResponseBody(resp)
 
 	if err != nil {
-		return PRESENCE_NOT_PRESENT, &err
+		redfishPingDuration.WithLabelValues(PROBE_ERROR).Observe(time.Since(start).Seconds())
+		return PROBE_ERROR, RedfishServiceRoot{}, &err
 	}
 
 	// Ensure we clean up any stray connection
@@ -613,143 +816,109 @@ func queryNetworkStatusViaAddress(address string) (HSMEndpointPresence, *error)
 		strbody, _ = ioutil.ReadAll(resp.Body)
 	}
 
-	if resp.StatusCode == 200 {
-		return PRESENCE_PRESENT, nil
+	result, root, err := classifyServiceRoot(resp.StatusCode, strbody)
+	redfishPingDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+	if err != nil {
+		return result, root, &err
 	}
-
-	// else ...
-	emsg := fmt.Errorf("Bad return status: (%d): %v",
-		resp.StatusCode, string(strbody))
-	return PRESENCE_NOT_PRESENT, &emsg
+	return result, root, nil
 }
 
-func queryNetworkStatus(ne NetEndpoint) (HSMEndpointPresence, *string, *error) {
-	var res HSMEndpointPresence
-	var errn *error
-
+func queryNetworkStatus(ctx context.Context, ne *NetEndpoint) (HSMEndpointPresence, *string, *error) {
 	if ne.name == "" {
 		err := fmt.Errorf("endpoint name cannot be empty!")
 		return PRESENCE_NOT_PRESENT, nil, &err
 	}
 
-	res, errn = queryNetworkStatusViaAddress(ne.name)
-	if res == PRESENCE_PRESENT {
-		return PRESENCE_PRESENT, &(ne.name), nil
+	address := ne.name
+	if ne.address != "" {
+		address = ne.address
+	}
+
+	result, root, errn := queryNetworkStatusViaAddress(ctx, address)
+	ne.recordServiceRoot(result, root)
+	if result == PROBE_PRESENT {
+		return PRESENCE_PRESENT, &address, nil
 	}
 
-	rerr := fmt.Errorf("Not found. Tried %s: %s", ne.name, *errn)
+	rerr := fmt.Errorf("Not found (%s). Tried %s: %s", result, address, *errn)
 	return PRESENCE_NOT_PRESENT, nil, &rerr
 }
 
+// watchForHardware watches a single endpoint on its own one-worker
+// scheduler until quit is closed or, if given, loopLimit pings have been
+// made.
 func watchForHardware(
 	ne *NetEndpoint,
 	quit chan struct{},
-	netQuery func(NetEndpoint) (HSMEndpointPresence, *string, *error),
-	onPresent func(NetEndpoint, string) *error,
-	onNotPresent func(NetEndpoint) *error,
+	netQuery func(context.Context, *NetEndpoint) (HSMEndpointPresence, *string, *error),
+	onPresent func(context.Context, *NetEndpoint, string) *error,
+	onNotPresent func(context.Context, NetEndpoint) *error,
 	loopLimit ...int) {
 
-	var prevErr string
-	var loopCount = 0
-
-	log.Printf("INFO: Starting query thread for %s.  It is currently %s in HSM", ne.name,
-		HSMEndpointPresenceToString[ne.HSMPresence])
-
-	// Set the time for the fixed (minimum) wait between checkups
-	// including a randomized wait at the start
-	ticker := time.NewTicker(time.Duration(rand.Float32() * float32(startupVariableWaitMax) * float32(time.Second)))
-
-	for {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
 		select {
-		case <-ticker.C:
-
-			// just to be safe, stop the ticker before we replace it...
-			ticker.Stop()
-
-			// Variable length wait at the start of checkup to make sure any bunching of checkup threads eventually shifts apart
-			ticker = time.NewTicker(time.Duration((float32(checkupFixedWait) + (rand.Float32()-0.5)*2*float32(checkupVariableWaitMax)) * float32(time.Second)))
-
-			go func() {
-				ne.HSMPresLock.Lock()
-				defer ne.HSMPresLock.Unlock()
-				netPresence, addr, err := netQuery(*ne)
-				if (err != nil) && (prevErr == "") {
-					netPresence = ne.HSMPresence // ensure no state change on FIRST failure (but do one on second)
-				}
-				if err != nil {
-					prevErr = fmt.Sprintf("%v", *err)
-				} else {
-					prevErr = ""
-				}
-
-				// Dont want to move items to present if there was an error reaching them.
-				if netPresence == PRESENCE_PRESENT && ne.HSMPresence == PRESENCE_NOT_PRESENT && err == nil {
-					err := (onPresent(*ne, *addr))
-					if err != nil {
-						log.Printf("WARNING: Failed to notify HSM that %s is now present: %v", ne.name, *err)
-					} else {
-						log.Printf("INFO: Marked %s ([%s]) present in HSM.", ne.name, *addr)
-						ne.HSMPresence = PRESENCE_PRESENT
-					}
-				} else if netPresence == PRESENCE_NOT_PRESENT && ne.HSMPresence == PRESENCE_PRESENT {
-					err := onNotPresent(*ne)
-					if err != nil {
-						log.Printf("WARNING: Failed to notify HSM that %s is NOT present: %v", ne.name, *err)
-					} else {
-						log.Printf("INFO: Lost network contact with %s", ne.name)
-					}
-				}
-			}()
-
-			if len(loopLimit) > 0 {
-				if loopLimit[0] != 0 {
-					loopCount++
-					if loopCount >= loopLimit[0] {
-						log.Printf("INFO: Quitting monitor thread for %s due to hitting loop count limit", ne.name)
-						ticker.Stop()
-						return
-					}
-				}
-			}
 		case <-quit:
-			log.Printf("INFO: Quitting monitor thread for %s", ne.name)
-			ticker.Stop()
-			return
+			cancel()
+		case <-ctx.Done():
 		}
+	}()
+
+	s := NewProbeScheduler(1, 0, netQuery, onPresent, onNotPresent)
+	if len(loopLimit) > 0 {
+		s.loopLimit = loopLimit[0]
 	}
+	s.Add(ne, "")
+	s.Run(ctx, context.Background())
 }
 
-func watchForHSMChanges(quit chan struct{}) {
-	log.Printf("INFO: Starting HSM query thread.")
+// rediscoverEndpoint immediately pings an endpoint and, if it answers,
+// runs the full initialization path (credentials, NetworkProtocol and
+// HSM registration) regardless of the cached HSM presence.
+func rediscoverEndpoint(
+	ctx context.Context,
+	ne *NetEndpoint,
+	netQuery func(context.Context, *NetEndpoint) (HSMEndpointPresence, *string, *error),
+	onPresent func(context.Context, *NetEndpoint, string) *error) RediscoverResult {
 
-	// Sync up with HSM every 5 mins
-	ticker := time.NewTicker(300 * time.Second)
-	defer ticker.Stop()
+	result := RediscoverResult{Xname: ne.name}
 
-	// If we can't read from HSM try again every 30 seconds
-	errTicker := time.NewTicker(30 * time.Second)
-	defer errTicker.Stop()
+	ne.HSMPresLock.Lock()
+	defer ne.HSMPresLock.Unlock()
 
-	for {
-		select {
-		case <-ticker.C:
-			log.Printf("TRACE: Checking up on HSM....")
-			for {
-				select {
-				case <-errTicker.C:
-					err := queryHSMState()
-					if err == nil {
-						break
-					}
-				case <-quit:
-					return
-				}
-			}
-		case <-quit:
-			log.Printf("INFO: Quitting HSM monitor thread")
-			return
-		}
-	}
+	log.Printf("INFO: Rediscovery requested for %s", ne.name)
+
+	netPresence, addr, err := netQuery(ctx, ne)
+	ne.lastPing = time.Now()
+	ne.lastPingPresence = netPresence
+	if err != nil {
+		ne.lastPingErr = fmt.Sprintf("%v", *err)
+		result.Error = ne.lastPingErr
+		log.Printf("WARNING: Unable to rediscover %s: %s", ne.name, result.Error)
+		return result
+	}
+	ne.lastPingErr = ""
+	if netPresence != PRESENCE_PRESENT || addr == nil {
+		result.Error = "endpoint is not present on the network"
+		log.Printf("WARNING: Unable to rediscover %s: %s", ne.name, result.Error)
+		return result
+	}
+	result.Address = *addr
+
+	perr := onPresent(ctx, ne, *addr)
+	if perr != nil {
+		result.Error = fmt.Sprintf("%v", *perr)
+		log.Printf("WARNING: Failed to rediscover %s: %s", ne.name, result.Error)
+		return result
+	}
+
+	log.Printf("INFO: Rediscovered %s ([%s]) and marked it present in HSM.", ne.name, *addr)
+	ne.setHSMPresence(PRESENCE_PRESENT)
+	ne.fingerprint = bmcFingerprint(ne)
+	result.Success = true
+	return result
 }
 
 // Do the dirty work of setting a parameter from an env var.
@@ -818,6 +987,54 @@ func getEnvVars() {
 	if envstr != "" {
 		sls = envstr
 	}
+	envstr = os.Getenv("MEDS_HTTP_LISTEN")
+	if envstr != "" {
+		httpListen = envstr
+	}
+	envstr = os.Getenv("MEDS_ABSENT_POLICY")
+	if envstr != "" {
+		absentPolicy = envstr
+	}
+	envstr = os.Getenv("MEDS_COMPLIANCE_REMEDIATE")
+	if envstr != "" {
+		complianceRemediate, _ = strconv.ParseBool(envstr)
+	}
+	envstr = os.Getenv("MEDS_CREDENTIAL_CHECK")
+	if envstr != "" {
+		credentialCheck = envstr
+	}
+	envstr = os.Getenv("MEDS_CREDENTIAL_ROTATION")
+	if envstr != "" {
+		credentialRotation, _ = strconv.ParseBool(envstr)
+	}
+	envstr = os.Getenv("MEDS_CEC")
+	if envstr != "" {
+		cecDiscovery, _ = strconv.ParseBool(envstr)
+	}
+	envstr = os.Getenv("MEDS_SLS_INVENTORY")
+	if envstr != "" {
+		slsInventory, _ = strconv.ParseBool(envstr)
+	}
+	envstr = os.Getenv("MEDS_TOPOLOGY_FILE")
+	if envstr != "" {
+		topologyFile = envstr
+	}
+	envstr = os.Getenv("MEDS_MAC_SCHEMES")
+	if envstr != "" {
+		macSchemes = envstr
+	}
+	envstr = os.Getenv("MEDS_GEOLOCATION_CHECK")
+	if envstr != "" {
+		geolocationCheck = envstr
+	}
+	envstr = os.Getenv("MEDS_VENDORS")
+	if envstr != "" {
+		serviceRootVendorList = envstr
+	}
+	envstr = os.Getenv("MEDS_SCN_URL")
+	if envstr != "" {
+		scnURL = envstr
+	}
 	envstr = os.Getenv("MEDS_CA_URI")
 	if envstr != "" {
 		hms_ca_uri = envstr
@@ -847,111 +1064,121 @@ func getEnvVars() {
 		}
 	}
 	__setenv_int("MEDS_HTTP_TIMEOUT", 1, &clientTimeout)
+	__setenv_int("MEDS_SLS_READY_WINDOW", 1, &slsReadyWindow)
+	__setenv_int("MEDS_PROBE_WORKERS", 1, &probeWorkers)
+	__setenv_int("MEDS_HSM_SYNC_INTERVAL", 1, &hsmSyncInterval)
+	__setenv_int("MEDS_HSM_SYNC_MAX_BACKOFF", 1, &hsmSyncMaxBackoff)
+	__setenv_int("MEDS_HSM_SYNC_FALLBACK_INTERVAL", 1, &hsmSyncFallbackInterval)
+	__setenv_int("MEDS_SHUTDOWN_TIMEOUT", 0, &shutdownTimeout)
+	__setenv_int("MEDS_ABSENT_GRACE", 0, &absentGrace)
+	__setenv_int("MEDS_PROBE_CABINET_LIMIT", 0, &probeCabinetLimit)
+	__setenv_int("MEDS_COMPLIANCE_INTERVAL", 0, &complianceInterval)
+	__setenv_int("MEDS_INIT_RETRIES", 0, &initRetries)
 }
 
-func init_chassis(cabinet, chassis sls_common.GenericHardware) error {
-	//
-	// Parse the chassis xname
-	//
-	chassisXnameRaw := xnames.FromString(chassis.Xname)
-	if chassisXnameRaw == nil {
-		return fmt.Errorf("INTERNAL ERROR, unable to parse chassis xname '%v'", chassis.Xname)
+func init_chassis(ctx context.Context, cabinet, chassis sls_common.GenericHardware, inventory *ChassisInventory) error {
+	endpoints, err := chassisEndpoints(cabinet, chassis, inventory)
+	if err != nil {
+		return err
 	}
 
-	chassisXname, ok := chassisXnameRaw.(xnames.Chassis)
-	if !ok {
-		return fmt.Errorf("INTERNAL ERROR, chassis xname strcture for '%v' is of type '%T' expected 'xnames.Chassis'", chassis.Xname, chassis)
-	}
+	return addChassisEndpoints(ctx, cabinet, chassis, endpoints)
+}
 
-	if chassisXname.Parent().String() != cabinet.Xname {
-		return fmt.Errorf("unable to initialize chassis, provided cabinet (%v) is not the parent of provided chassis (%v)",
-			cabinet.Xname, chassis.Parent)
+// update_chassis stops watching the endpoints SLS no longer has in an
+// active chassis and starts watching the ones it has gained, leaving the
+// rest of the chassis alone.
+func update_chassis(ctx context.Context, cabinet, chassis sls_common.GenericHardware, added, removed []*NetEndpoint) error {
+	for _, ne := range removed {
+		stopWatching(chassis.Xname, ne.name)
 	}
-
-	//
-	// Extract cabinet specific overrides from SLS
-	//
-	var cabExtra sls_common.ComptypeCabinet
-	ce, baerr := json.Marshal(cabinet.ExtraPropertiesRaw)
-	if baerr != nil {
-		err := fmt.Errorf("INTERNAL ERROR, can't marshal cab props: %v",
-			baerr)
-		log.Println(err)
-		return err
+	if len(added) == 0 {
+		return nil
 	}
+	return addChassisEndpoints(ctx, cabinet, chassis, added)
+}
 
-	baerr = json.Unmarshal(ce, &cabExtra)
-	if baerr != nil {
-		err := fmt.Errorf("INTERNAL ERROR, can't unmarshal cab props: %v",
-			baerr)
-		log.Println(err)
+// addChassisEndpoints adds the EthernetInterfaces of endpoints in a chassis
+// to HSM and starts watching them.  Must be called with activeEndpointsLock
+// held.
+func addChassisEndpoints(ctx context.Context, cabinet, chassis sls_common.GenericHardware, endpoints []*NetEndpoint) error {
+	// Nothing can be written to HSM safely if MAC addresses collide.
+	err := checkMACCollisions(endpoints, activeEndpoints)
+	if err != nil {
+		log.Printf("ERROR: Not initializing chassis %s: %v", chassis.Xname, err)
 		return err
 	}
 
-	// Make sure the map checks out before reaching into it to avoid panic.
-	hmnNetwork, networkExists := cabExtra.Networks["cn"]["HMN"]
-	if !networkExists {
-		err := fmt.Errorf("cabinet doesn't have HMN network for compute nodes: %+v", cabExtra)
+	// Determine what ethernet interfaces need to be get added or updated.
+	hsmEthernetInterfaces, err := dhcpdnsClient.GetAllEthernetInterfaces()
+	recordResult("hsm", "get_ethernet_interfaces", err)
+	if err != nil {
+		log.Println("Failed to get ethernet interfaces from HSM, not processing further: ", err)
 		return err
 	}
 
-	macPrefix := ""
-	if hmnNetwork.MACPrefix != "" {
-		macPrefix = hmnNetwork.MACPrefix
-	} else {
-		// Default
-		macPrefix = MAC_PREFIX
+	macPrefix, scheme, _ := cabinetMACSettings(cabinet)
+	for _, mismatch := range findMismatchedEthernetInterfaces(scheme, macPrefix, chassis.Xname, hsmEthernetInterfaces) {
+		log.Printf("WARNING: HSM EthernetInterface %s has CompID %s, but its MAC address encodes %s",
+			mismatch.MACAddr, mismatch.CompID, mismatch.EncodedXname)
 	}
 
-	// Generate the list of endpoints that MEDS should look for contained within in this chassis.
-	endpoints := make([]*NetEndpoint, 0)
-	// The CECs haven't ever been populated by HSM, since we don't generate an algorthmic MAC address for them.
-	// endpoints = append(endpoints, GenerateEnvironmentalControllerEndpoints(rackNum)...)
-	endpoints = append(endpoints, GenerateChassisEndpoints(macPrefix, chassisXname.Cabinet, []int{chassisXname.Chassis})...)
-
-	// Determine what ethernet interfaces need to be get added or updated.
-	hsmEthernetInterfaces, err := dhcpdnsClient.GetAllEthernetInterfaces()
+	err = applyEthernetInterfaces(planEthernetInterfaces(endpoints, hsmEthernetInterfaces))
 	if err != nil {
-		log.Println("Failed to get ethernet interfaces from HSM, not processing further: ", err)
+		// If the add to HSM fails don't add the endpoint to any lists and instead skip over it so we process it again.
+		// The main loop will try to re-initialize the cabinet
 		return err
 	}
-	hsmEthernetInterfacesMap := map[string]sm.CompEthInterfaceV2{}
-	for _, ei := range hsmEthernetInterfaces {
-		hsmEthernetInterfacesMap[ei.ID] = ei
-	}
 
-	// Generate MAC addresses for hardware in this chassis
-	for _, v := range endpoints {
-		normalizedMAC := strings.ToLower(strings.ReplaceAll(v.mac, ":", ""))
+	log.Printf("INFO: Finished adding EthernetInterfaces to HSM for chassis %s", chassis.Xname)
 
-		// Check to see if the generated endpoint has a MAC address associated with it.
-		// Currently MEDS does generate a MAC addresses fro CEC's. Ex: x5000e0, x5000e1
-		if normalizedMAC == "" {
-			log.Printf("WARN: Endpoint has no MAC address: %s", v.name)
-			continue
+	// Verify that the FQDN/Hostname for RedfishEndpoints in HSM are what we expect
+	verifyCabinetRedfishEndpoints(ctx, endpoints)
+
+	// Start watching for hardware
+	startWatching(chassis.Xname, cabinet.Xname, endpoints)
+	return nil
+}
+
+// startWatching tracks endpoints under a chassis (or, for CECs, a cabinet)
+// and starts pinging them.  Must be called with activeEndpointsLock held.
+func startWatching(group, cabinet string, endpoints []*NetEndpoint) {
+	for _, v := range endpoints {
+		// Determine if this redfish endpoint is known in state manager
+		hsmRedfishEndpointsCacheLock.Lock()
+		if rfEP, known := hsmRedfishEndpointsCache[v.name]; known {
+			v.HSMPresence = PRESENCE_PRESENT
+			v.fingerprint = rfEP.UUID
 		}
+		hsmRedfishEndpointsCacheLock.Unlock()
+
+		// Now add endpoints to activeCabinets and
+		activeChassis[group] = append(activeChassis[group], v)
+		activeEndpoints[v.name] = v
 
-		// Preload HSM EthernetInterfaces with the endpoints.
+		// Start pinging the endpoint
+		probeScheduler.Add(v, cabinet)
+	}
+}
+
+// applyEthernetInterfaces POSTs or PATCHes the planned EthernetInterfaces
+// into HSM, stopping at the first failure.
+func applyEthernetInterfaces(actions []EthernetInterfaceAction) error {
+	for _, action := range actions {
 		ethernetInterface := sm.CompEthInterfaceV2{
-			MACAddr: normalizedMAC,
-			CompID:  v.name,
+			MACAddr: action.MACAddr,
+			CompID:  action.CompID,
 		}
 
-		// POST/PATCH ethernet interfaces into HSM
-		if hsmEI, ok := hsmEthernetInterfacesMap[ethernetInterface.MACAddr]; ok && hsmEI.CompID == ethernetInterface.CompID {
-			// The MAC address is currently in HSM with the same component ID
-			log.Printf("INFO: Ethernet interface for MAC %s and CompID %s already present in HSM", ethernetInterface.MACAddr, ethernetInterface.CompID)
-		} else if ok && hsmEI.CompID != ethernetInterface.CompID {
+		if action.Action == http.MethodPatch {
 			// The MAC address is currently in HSM with a different component ID
-			log.Printf("INFO: Patching ethernet interface with MAC %s. HSM has CompID %s want %s.", ethernetInterface.MACAddr, hsmEI.CompID, ethernetInterface.CompID)
+			log.Printf("INFO: Patching ethernet interface with MAC %s. HSM has CompID %s want %s.", ethernetInterface.MACAddr, action.CurrentCompID, ethernetInterface.CompID)
 			patchErr := dhcpdnsClient.PatchEthernetInterface(ethernetInterface)
+			recordResult("hsm", "patch_ethernet_interface", patchErr)
 
 			if patchErr != nil {
 				log.Println("ERROR: Failed to patch ethernet interface to HSM, not processing further: ", patchErr)
 				log.Printf("Interface: %+v", ethernetInterface)
-
-				// If the add to HSM fails don't add the endpoint to any lists and instead skip over it so we process it again.
-				// The main loop will try to re-initialize the cabinet
 				return patchErr
 			}
 
@@ -959,82 +1186,36 @@ func init_chassis(cabinet, chassis sls_common.GenericHardware) error {
 		} else {
 			// Add the new ethernet interface. Patches instead if it's already present just in case
 			addErr := dhcpdnsClient.AddNewEthernetInterface(ethernetInterface, true)
+			recordResult("hsm", "add_ethernet_interface", addErr)
 
 			if addErr != nil {
 				log.Println("Failed to add new ethernet interface to HSM, not processing further: ", addErr)
 				log.Printf("Interface: %+v", ethernetInterface)
-
-				// If the add to HSM fails don't add the endpoint to any lists and instead skip over it so we process it again.
-				// The main loop will try to re-initialize the cabinet
 				return addErr
 			}
 
 			log.Printf("INFO: Added new ethernet interface to HSM: %+v", ethernetInterface.CompID)
 		}
 	}
-
-	log.Printf("INFO: Finished adding EthernetInterfaces to HSM for chassis %s", chassis.Xname)
-
-	// Verify that the FQDN/Hostname for RedfishEndpoints in HSM are what we expect
-	verifyCabinetRedfishEndpoints(endpoints)
-
-	// Start watching for hardware
-	for _, v := range endpoints {
-		// Create a channel we can use to kill this later
-		v.QuitChannel = make(chan struct{})
-
-		// Determine if this redfish endpoint is known in state manager
-		hsmRedfishEndpointsCacheLock.Lock()
-		if _, known := hsmRedfishEndpointsCache[v.name]; known {
-			v.HSMPresence = PRESENCE_PRESENT
-		}
-		hsmRedfishEndpointsCacheLock.Unlock()
-
-		// Now add endpoints to activeCabinets and
-		activeChassis[chassis.Xname] = append(activeChassis[chassis.Xname], v)
-		activeEndpoints[v.name] = v
-
-		// Start hardware polling thread
-		go watchForHardware(v, v.QuitChannel, queryNetworkStatus, notifyXnamePresent,
-			notifyHSMXnameNotPresent)
-	}
-
 	return nil
 }
 
-func verifyCabinetRedfishEndpoints(endpoints []*NetEndpoint) error {
+func verifyCabinetRedfishEndpoints(ctx context.Context, endpoints []*NetEndpoint) error {
 	// Verify that the FQDN/Hostname for RedfishEndpoints in HSM are what we expect
-	for _, v := range endpoints {
-		// Determine if this redfish endpoint is known in state manager and if it has the correct FQDN
-		hsmRedfishEndpointsCacheLock.Lock()
-		updateRFEndpoint := false
-		var fqdn, hostname string
-		if rfEP, known := hsmRedfishEndpointsCache[v.name]; known {
-
-			// Verify that ChassisBMC's have the correct FQDN/hostname values set
-			// TODO For authoritative DNS the following check should be changed to handle the FQDN of the system.
-			if xnametypes.GetHMSType(rfEP.ID) == xnametypes.ChassisBMC && rfEP.ID != rfEP.FQDN {
-				fqdn = rfEP.ID
-				hostname = rfEP.ID
-				log.Printf("Found ChassisBMC RedfishEndpoint with ID (%s) and FQDN (%s) PATCHING HSM to use FQDN (%s) and Hostname (%s)\n",
-					v.name, rfEP.FQDN, fqdn, hostname)
-
-				updateRFEndpoint = true
-				rfEP.FQDN = fqdn
-			}
-		}
-		hsmRedfishEndpointsCacheLock.Unlock()
-
-		// Update the redfish endpoint if applicable
-		if updateRFEndpoint {
-			err := patchXnameFQDN(v.name, fqdn, hostname)
-			if err != nil {
-				log.Printf("Failed to update RedfishEndpoint (%s) in HSM with new FQDN/Hostname, not processing further: %v\n", v.name, err)
+	hsmRedfishEndpointsCacheLock.Lock()
+	fixes := planFQDNFixes(endpoints, hsmRedfishEndpointsCache)
+	hsmRedfishEndpointsCacheLock.Unlock()
+
+	for _, fix := range fixes {
+		log.Printf("Found ChassisBMC RedfishEndpoint with ID (%s) and FQDN (%s) PATCHING HSM to use FQDN (%s) and Hostname (%s)\n",
+			fix.Xname, fix.CurrentFQDN, fix.FQDN, fix.Hostname)
+		err := patchXnameFQDN(ctx, fix.Xname, fix.FQDN, fix.Hostname)
+		if err != nil {
+			log.Printf("Failed to update RedfishEndpoint (%s) in HSM with new FQDN/Hostname, not processing further: %v\n", fix.Xname, err)
 
-				// If the add to HSM fails don't add the endpoint to any lists and instead skip over it so we process it again.
-				// The main loop will try to re-initialize the cabinet
-				return err
-			}
+			// If the add to HSM fails don't add the endpoint to any lists and instead skip over it so we process it again.
+			// The main loop will try to re-initialize the cabinet
+			return err
 		}
 	}
 
@@ -1043,16 +1224,30 @@ func verifyCabinetRedfishEndpoints(endpoints []*NetEndpoint) error {
 
 func deinit_chassis(k string) {
 	// Iterate through the endpoints in the chassis and stop them
-	for endp := range activeChassis[k] {
-		log.Printf("TRACE: quitting %s", activeChassis[k][endp].name)
-		activeChassis[k][endp].QuitChannel <- struct{}{}
-		delete(activeEndpoints, activeChassis[k][endp].name)
+	for _, ne := range append([]*NetEndpoint{}, activeChassis[k]...) {
+		stopWatching(k, ne.name)
 	}
 
 	// Remove from active cabinets
 	delete(activeChassis, k)
 }
 
+// stopWatching stops pinging an endpoint and drops it from its chassis.
+// Must be called with activeEndpointsLock held.
+func stopWatching(group, xname string) {
+	log.Printf("TRACE: quitting %s", xname)
+	probeScheduler.Remove(xname)
+	delete(activeEndpoints, xname)
+
+	endpoints := activeChassis[group]
+	for i, ne := range endpoints {
+		if ne.name == xname {
+			activeChassis[group] = append(endpoints[:i:i], endpoints[i+1:]...)
+			break
+		}
+	}
+}
+
 // This function is used to set up an HTTP validated/non-validated client
 // pair for Redfish operations.  This is done at the start of things, and also
 // whenever the CA chain bundle is "rolled".
@@ -1078,6 +1273,7 @@ func setupRFHTTPStuff() error {
 		return fmt.Errorf("ERROR: Can't create TLS cert-enabled HTTP client: %v", err)
 	}
 	log.Printf("INFO: TLS-secured Redfish client successfully created.")
+	medsHealth.setRFTLSValidated(validatesTLS(rfClient))
 
 	var nwp bmc_nwprotocol.NWPData
 	nwp.SyslogSpec = syslogTarg
@@ -1091,12 +1287,164 @@ func setupRFHTTPStuff() error {
 	return nil
 }
 
+// validatesTLS reports whether the secure half of 'pair' verifies the BMCs'
+// certificates, rather than whether a CA URI was configured.
+func validatesTLS(pair *hms_certs.HTTPClientPair) bool {
+	if pair == nil || pair.SecureClient == nil || pair.SecureClient.HTTPClient == nil {
+		return false
+	}
+	transport, ok := pair.SecureClient.HTTPClient.Transport.(*http.Transport)
+	if !ok || transport.TLSClientConfig == nil {
+		return false
+	}
+	return !transport.TLSClientConfig.InsecureSkipVerify
+}
+
 func caChangeCB(caBundle string) {
 	log.Printf("INFO: CA bundle rolled; waiting for all RF threads to pause...")
 	setupRFHTTPStuff()
 	log.Printf("INFO: HTTP transports/clients now set up with new CA bundle.")
 }
 
+// watchSLS periodically reads the Mountain and Hill cabinets and chassis
+// from SLS, starting to watch new chassis and dropping those that have
+// disappeared, until ctx is cancelled.  HSM updates for new chassis use
+// workCtx so they can be allowed to finish after ctx is cancelled.
+func watchSLS(ctx, workCtx context.Context) {
+	// With SLS enabled we want to update ourselves periodically.
+	basetime := 30 * time.Second
+	backoffTime := 5 * time.Second
+	maxtime := 5 * time.Minute
+	waittime := basetime
+	for {
+		log.Printf("INFO: Sleeping %d seconds before refreshing data", waittime/time.Second)
+		select {
+		case <-time.After(waittime):
+		case <-ctx.Done():
+			log.Printf("INFO: Quitting SLS monitor thread")
+			return
+		}
+
+		cabinets, err := getSLSCabInfo(ctx)
+		medsHealth.setSLSStatus(err)
+		if err != nil {
+			log.Printf("WARNING: Can't get cabinet list from SLS: %v\n",
+				err)
+			waittime += backoffTime
+			if waittime > maxtime {
+				waittime = maxtime
+			}
+			continue
+		}
+		if len(cabinets) == 0 {
+			log.Printf("INFO: No cabinets found in SLS.\n")
+		}
+
+		var inventory map[string]*ChassisInventory
+		if slsInventory {
+			inventory, err = getSLSChassisInventory(ctx)
+			medsHealth.setSLSStatus(err)
+			if err != nil {
+				log.Printf("WARNING: Can't get chassis inventory from SLS: %v\n", err)
+				waittime += backoffTime
+				if waittime > maxtime {
+					waittime = maxtime
+				}
+				continue
+			}
+		}
+
+		// List of chassis. We'll remove those we find in SLS from this
+		oldChassisList := make(map[string]bool, 0)
+		for k := range activeChassis {
+			oldChassisList[k] = true
+		}
+
+		rfClientLock.RLock()
+		activeEndpointsLock.Lock() // Take the lock so we can update!
+		for _, cabinet := range cabinets {
+			if ctx.Err() != nil {
+				// Shutting down; don't start on any more cabinets
+				break
+			}
+			log.Printf("TRACE: Handling cabinet %s from SLS", cabinet.Xname)
+
+			// Retrieve chassis present in the cabinet
+			cabinetChassis, err := getSLSCabinetChassis(ctx, cabinet.Xname)
+			if err != nil {
+				log.Printf("INTERNAL ERROR, failed to query SLS for chassis of '%v': %v", cabinet.Xname, err)
+				continue
+			}
+
+			if len(cabinetChassis) == 0 {
+				log.Printf("INFO: No chassis found for cabinet '%v' in SLS.", cabinet.Xname)
+			}
+
+			if cecDiscovery {
+				if _, ok := activeChassis[cabinet.Xname]; !ok {
+					cecs, err := getSLSHardwareOfType(ctx, sls_common.CEC, cabinet.Xname)
+					if err == nil {
+						err = init_cabinet_cecs(workCtx, cabinet, cecs)
+					}
+					if err != nil {
+						log.Printf("Error initializing CECs of cabinet %s: %s", cabinet.Xname, err)
+					}
+				}
+				delete(oldChassisList, cabinet.Xname)
+			}
+
+			for _, chassis := range cabinetChassis {
+				log.Printf("TRACE: Handling chassis %s from SLS", chassis.Xname)
+
+				if _, ok := activeChassis[chassis.Xname]; !ok {
+					log.Printf("TRACE: Chassis %s is new", chassis.Xname)
+					// Cabinet not present, need to set up and init everything
+					err := init_chassis(workCtx, cabinet, chassis, inventory[chassis.Xname])
+					if err != nil {
+						log.Printf("Error initializing cabinet: %s", err)
+						continue
+					}
+				} else {
+					// Else this cabinet is already present
+					log.Printf("TRACE: Chassis %s is not new", chassis.Xname)
+
+					// If SLS has gained or lost blades in this chassis only
+					// those endpoints are started or stopped.
+					var added, removed []*NetEndpoint
+					if slsInventory {
+						added, removed = inventoryChanges(cabinet, chassis, inventory[chassis.Xname])
+					}
+					if len(added)+len(removed) > 0 {
+						log.Printf("INFO: Inventory of chassis %s in SLS changed, adding %d and removing %d endpoints",
+							chassis.Xname, len(added), len(removed))
+						err := update_chassis(workCtx, cabinet, chassis, added, removed)
+						if err != nil {
+							log.Printf("Error updating chassis: %s", err)
+							continue
+						}
+					}
+				}
+
+				// No matter hat though, we need to remove it from oldCabList to account for finding it
+				delete(oldChassisList, chassis.Xname)
+			}
+		}
+
+		// Anything left in oldChassisList disappeared, unless we stopped
+		// part way through.
+		if ctx.Err() == nil {
+			for k := range oldChassisList {
+				deinit_chassis(k)
+			}
+		}
+
+		activeEndpointsLock.Unlock()
+		rfClientLock.RUnlock()
+
+		waittime = basetime
+	}
+}
+
 func main() {
 	var credentialsVault string
 	var err error
@@ -1121,10 +1469,95 @@ func main() {
 		"Vault prefix for storing MEDS credentials")
 	flag.IntVar(&maxInitialHSMSyncAttempts, "max-initial-hsm-sync-attempts", 30,
 		"Number of attempts to perform an initial sync with HSM")
+	flag.StringVar(&httpListen, "http-listen", ":8080",
+		"Address:port on which the MEDS status API listens")
+	flag.IntVar(&hsmSyncInterval, "hsm-sync-interval", hsmSyncInterval,
+		"Seconds between syncs with HSM")
+	flag.IntVar(&hsmSyncMaxBackoff, "hsm-sync-max-backoff", hsmSyncMaxBackoff,
+		"Maximum seconds between retries when syncing with HSM fails")
+	flag.StringVar(&scnURL, "scn-url", "",
+		"URL at which HSM should send state change notifications to MEDS, e.g. http://cray-meds:8080/v1/scn (empty to only poll HSM); HSM sends none for deleted or disabled RedfishEndpoints, which are only picked up by polling")
+	flag.IntVar(&hsmSyncFallbackInterval, "hsm-sync-fallback-interval", hsmSyncFallbackInterval,
+		"Seconds between full syncs with HSM, and between renewals of the subscription, once state change notifications are arriving")
+	flag.IntVar(&probeWorkers, "probe-workers", probeWorkers,
+		"Maximum number of Redfish pings in flight at once")
+	flag.IntVar(&probeCabinetLimit, "probe-cabinet-limit", probeCabinetLimit,
+		"Maximum number of Redfish pings in flight at once per cabinet (0 for no limit)")
+	flag.StringVar(&absentPolicy, "absent-policy", absentPolicy,
+		"What to do when an endpoint stops answering: 'ignore', 'disable' it in HSM, or mark it 'absent' so it is re-initialized when it reappears")
+	flag.IntVar(&absentGrace, "absent-grace", absentGrace,
+		"Seconds an endpoint must be unreachable before the absent policy is applied")
+	flag.StringVar(&geolocationCheck, "geolocation-check", geolocationCheck,
+		"Whether to check that BMCs report the location and MAC address MEDS expects: 'off', 'warn' (default), or 'enforce' to refuse to register mismatches")
+	flag.StringVar(&serviceRootVendorList, "vendors", serviceRootVendorList,
+		"Comma separated list of the Redfish service root vendors MEDS registers (empty for any vendor)")
+	flag.IntVar(&initRetries, "init-retries", initRetries,
+		"Times to retry a failed initialization of an endpoint (storing credentials, pushing NetworkProtocol, registering with HSM) sooner than the next regular ping")
+	flag.StringVar(&credentialCheck, "credential-check", credentialCheck,
+		"Whether to check that the Vault credentials of each BMC work before registering it: 'off', 'warn', or 'repair' to fall back to the MEDS credentials and fix Vault")
+	flag.BoolVar(&credentialRotation, "credential-rotation", false,
+		"Allow BMC credentials to be rotated through the API")
+	flag.IntVar(&shutdownTimeout, "shutdown-timeout", shutdownTimeout,
+		"Seconds to wait for in-flight requests to finish when shutting down")
+	flag.IntVar(&slsReadyWindow, "sls-ready-window", slsReadyWindow,
+		"Seconds since the last successful SLS query after which MEDS reports not ready")
+	flag.StringVar(&macSchemes, "mac-schemes", macSchemes,
+		"Comma separated class=scheme list of the MAC address scheme for each class of cabinet, e.g. 'Hill=default' (default scheme otherwise)")
+	flag.StringVar(&topologyFile, "topology-file", topologyFile,
+		"JSON file of chassis topologies and the topology for each class of cabinet")
+	flag.IntVar(&complianceInterval, "compliance-interval", complianceInterval,
+		"Seconds between checks of BMC NetworkProtocol settings; 0 disables the check")
+	flag.BoolVar(&complianceRemediate, "compliance-remediate", false,
+		"Push the NetworkProtocol settings again to BMCs that have drifted")
+	flag.BoolVar(&cecDiscovery, "cec", false,
+		"Discover and register the environmental controllers (CECs) of each cabinet")
+	flag.BoolVar(&slsInventory, "sls-inventory", false,
+		"Only watch the blade BMCs whose slots or BMCs are in SLS")
+	flag.BoolVar(&dryRun, "dry-run", false,
+		"Print the changes MEDS would make to HSM and BMCs as JSON and exit, without making them")
 	flag.Parse()
 
 	getEnvVars()
 
+	// SIGTERM (or ^C) cancels ctx, which stops every MEDS loop.  Requests
+	// already in flight use workCtx, which is only cancelled if they don't
+	// finish within the shutdown timeout.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	workCtx, cancelWork := context.WithCancel(context.Background())
+	defer cancelWork()
+
+	if !validAbsentPolicy(absentPolicy) {
+		log.Printf("ERROR: Unknown absent policy '%s', using '%s'", absentPolicy, ABSENT_POLICY_IGNORE)
+		absentPolicy = ABSENT_POLICY_IGNORE
+	}
+	log.Printf("INFO: Absent policy is '%s' with a grace period of %d seconds", absentPolicy, absentGrace)
+
+	if !validGeolocationCheck(geolocationCheck) {
+		log.Printf("ERROR: Unknown geolocation check '%s', using '%s'", geolocationCheck, GEOLOCATION_WARN)
+		geolocationCheck = GEOLOCATION_WARN
+	}
+	log.Printf("INFO: Geolocation check is '%s'", geolocationCheck)
+	serviceRootVendors = parseVendorList(serviceRootVendorList)
+	log.Printf("INFO: Registering BMCs from vendors %v", serviceRootVendors)
+	if !validCredentialCheck(credentialCheck) {
+		log.Printf("ERROR: Unknown credential check '%s', using '%s'", credentialCheck, CREDENTIAL_CHECK_WARN)
+		credentialCheck = CREDENTIAL_CHECK_WARN
+	}
+	log.Printf("INFO: Credential check is '%s'", credentialCheck)
+
+	macSchemesByClass, err = parseMACSchemes(macSchemes)
+	if err != nil {
+		log.Fatalf("ERROR: Bad MAC scheme list '%s': %v", macSchemes, err)
+	}
+
+	if topologyFile != "" {
+		err = loadTopologyFile(topologyFile)
+		if err != nil {
+			log.Fatalf("ERROR: Unable to load topology file: %v", err)
+		}
+	}
+
 	serviceName, err = base.GetServiceInstanceName()
 	if err != nil {
 		log.Printf("Can't get service instance (hostname)!  Setting to 'MEDS'")
@@ -1132,23 +1565,43 @@ func main() {
 	}
 	log.Printf("Service Instance Name: '%s'", serviceName)
 
-	log.Printf("Connecting to secure store (Vault)...")
-	// Start a connection to Vault
-	if ss, err := sstorage.NewVaultAdapter("secret"); err != nil {
-		log.Printf("Error: Secure Store connection failed - %s", err)
-		panic(err)
-	} else {
+	// Initialize pprof if enabled
+	PProfInit()
+
+	// Start the MEDS status API early so liveness/readiness are available
+	// while we wait on our dependencies.
+	if !dryRun {
+		startHTTPServer()
+	}
+
+	// Start a connection to Vault.  We can't do anything useful without it,
+	// so keep trying; readiness reports the failure in the meantime.  A dry
+	// run only reads, so it doesn't need credentials from Vault.
+	if !dryRun {
+		log.Printf("Connecting to secure store (Vault)...")
+	}
+	for !dryRun {
+		ss, err := sstorage.NewVaultAdapter("secret")
+		medsHealth.setVaultStatus(err)
+		if err != nil {
+			log.Printf("Error: Secure Store connection failed - %s; trying again in 5 seconds", err)
+			select {
+			case <-time.After(5 * time.Second):
+			case <-ctx.Done():
+				shutdown(cancelWork)
+				return
+			}
+			continue
+		}
 		log.Printf("Connection to secure store (Vault) succeeded")
 		credStorage = model.NewMedsCredStore(credentialsVault, ss)
 		hcs = compcreds.NewCompCredStore("hms-creds", ss)
+		break
 	}
 
 	//Set up DNS/DHCP
 	dhcpdnsClient = dns_dhcp.NewDHCPDNSHelperInstance(hsm, nil, serviceName)
 
-	// Initialize pprof if enabled
-	PProfInit()
-
 	//Set up RF HTTP client and NWP stuff
 
 	hms_certs.InitInstance(nil, serviceName)
@@ -1228,6 +1681,14 @@ func main() {
 		}
 	}
 
+	if dryRun {
+		err = runDryRun(ctx, os.Stdout)
+		if err != nil {
+			log.Fatalf("ERROR: Unable to build dry run plan: %v", err)
+		}
+		return
+	}
+
 	if hms_ca_uri != "" {
 		err = hms_certs.CAUpdateRegister(hms_ca_uri, caChangeCB)
 		if err != nil {
@@ -1242,15 +1703,22 @@ func main() {
 	}
 
 	/* Start up watch for HSM changes early, so we can loop over data */
-	HSMPollquitc := make(chan struct{})
+	hsmReconciler = NewHSMReconciler(time.Duration(hsmSyncInterval)*time.Second,
+		time.Duration(hsmSyncRetryBase)*time.Second,
+		time.Duration(hsmSyncMaxBackoff)*time.Second, queryHSMState)
 
 	// Perform an initial sync with HSM before starting, so we now the state of the redfish endpoints
 	// This will help prevent MEDS from flooding HSM with discoveries when it starts up
 	for attempt := 1; attempt <= maxInitialHSMSyncAttempts; attempt++ {
-		err := queryHSMState()
+		err := hsmReconciler.SyncOnce(ctx)
 		if err != nil {
 			log.Printf("Initial sync with HSM failed. attempt %d of %d", attempt, maxInitialHSMSyncAttempts)
-			time.Sleep(time.Second)
+			select {
+			case <-time.After(time.Second):
+			case <-ctx.Done():
+				shutdown(cancelWork)
+				return
+			}
 		} else {
 			log.Printf("Successfully performed initial sync with HSM")
 			break
@@ -1261,84 +1729,32 @@ func main() {
 		}
 	}
 
-	// TODO I'll have to rewrite how this is handled, I think.  Or at least move the function into the thread
-	go watchForHSMChanges(HSMPollquitc)
-
-	// With SLS enabled we want to update ourselves periodically.
-	basetime := 30 * time.Second
-	backoffTime := 5 * time.Second
-	maxtime := 5 * time.Minute
-	waittime := basetime
-	for {
-		log.Printf("INFO: Sleeping %d seconds before refreshing data", waittime/time.Second)
-		time.Sleep(waittime)
-
-		cabinets, err := getSLSCabInfo()
-		if err != nil {
-			log.Printf("WARNING: Can't get cabinet list from SLS: %v\n",
-				err)
-			waittime += backoffTime
-			if waittime > maxtime {
-				waittime = maxtime
-			}
-			continue
-		}
-		if len(cabinets) == 0 {
-			log.Printf("INFO: No cabinets found in SLS.\n")
-		}
-
-		// List of chassis. We'll remove those we find in SLS from this
-		oldChassisList := make(map[string]bool, 0)
-		for k := range activeChassis {
-			oldChassisList[k] = true
-		}
-
-		rfClientLock.RLock()
-		activeEndpointsLock.Lock() // Take the lock so we can update!
-		for _, cabinet := range cabinets {
-			log.Printf("TRACE: Handling cabinet %s from SLS", cabinet.Xname)
-
-			// Retrieve chassis present in the cabinet
-			cabinetChassis, err := getSLSCabinetChassis(cabinet.Xname)
-			if err != nil {
-				log.Printf("INTERNAL ERROR, failed to query SLS for chassis of '%v': %v", cabinet.Xname, err)
-				continue
-			}
-
-			if len(cabinetChassis) == 0 {
-				log.Printf("INFO: No chassis found for cabinet '%v' in SLS.", cabinet.Xname)
-			}
-
-			for _, chassis := range cabinetChassis {
-				log.Printf("TRACE: Handling chassis %s from SLS", chassis.Xname)
-
-				if _, ok := activeChassis[chassis.Xname]; !ok {
-					log.Printf("TRACE: Chassis %s is new", chassis.Xname)
-					// Cabinet not present, need to set up and init everything
-					err := init_chassis(cabinet, chassis)
-					if err != nil {
-						log.Printf("Error initializing cabinet: %s", err)
-						continue
-					}
-				} else {
-					// Else this cabinet is already present
-					// Take no action
-					log.Printf("TRACE: Chassis %s is not new", chassis.Xname)
-				}
-
-				// No matter hat though, we need to remove it from oldCabList to account for finding it
-				delete(oldChassisList, chassis.Xname)
-			}
-		}
+	go hsmReconciler.Run(ctx)
+	if scnURL != "" {
+		go watchSCNSubscription(ctx, scnURL)
+	} else {
+		log.Printf("INFO: No SCN URL specified, relying on polling HSM for changes.")
+	}
 
-		// Anything left in oldChassisList disappeared.
-		for k := range oldChassisList {
-			deinit_chassis(k)
-		}
+	// Endpoints are added to the probe scheduler as chassis are found in SLS
+	probeScheduler = NewProbeScheduler(probeWorkers, probeCabinetLimit,
+		queryNetworkStatus, notifyXnamePresent, notifyHSMXnameNotPresent)
+	probesDone := make(chan struct{})
+	go func() {
+		probeScheduler.Run(ctx, workCtx)
+		close(probesDone)
+	}()
 
-		activeEndpointsLock.Unlock()
-		rfClientLock.RUnlock()
+	slsDone := make(chan struct{})
+	go func() {
+		watchSLS(ctx, workCtx)
+		close(slsDone)
+	}()
 
-		waittime = basetime
+	if complianceInterval > 0 {
+		go watchCompliance(ctx)
 	}
+
+	<-ctx.Done()
+	shutdown(cancelWork, slsDone, probesDone)
 }
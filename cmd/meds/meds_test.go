@@ -26,6 +26,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -39,6 +40,8 @@ import (
 
 	base "github.com/Cray-HPE/hms-base/v2"
 	"github.com/Cray-HPE/hms-certs/pkg/hms_certs"
+
+	"github.com/Cray-HPE/hms-meds/internal/macaddr"
 )
 
 func NetEndpointEquals(a NetEndpoint, b NetEndpoint) bool {
@@ -55,38 +58,6 @@ func NetEndpointEquals(a NetEndpoint, b NetEndpoint) bool {
 	return true
 }
 
-func Test_GenerateMAC(t *testing.T) {
-	ret := GenerateMAC("02", 7, 5, 3, 1)
-	ec := "02:00:07:05:03:10"
-	if ret != ec {
-		t.Errorf("Generated MAC did not match expectation:\nExpectation:\n%v\nGot:\n%v\n", ec, ret)
-	}
-}
-
-func Test_GenerateMACnC(t *testing.T) {
-	ret := GenerateMACnC("02", 7, 5, 3, 1)
-	ec := "02:00:07:05:33:10"
-	if ret != ec {
-		t.Errorf("Generated nC MAC did not match expectation:\nExpectation:\n%v\nGot:\n%v\n", ec, ret)
-	}
-}
-
-func Test_GenerateMACsC(t *testing.T) {
-	ret := GenerateMACsC("02", 7, 5, 3)
-	ec := "02:00:07:05:63:00"
-	if ret != ec {
-		t.Errorf("Generated sC MAC did not match expectation:\nExpectation:\n%v\nGot:\n%v\n", ec, ret)
-	}
-}
-
-func Test_GenerateMACcC(t *testing.T) {
-	ret := GenerateMACcC("02", 7, 5)
-	ec := "02:00:07:05:00:00"
-	if ret != ec {
-		t.Errorf("Generated cC MAC did not match expectation:\nExpectation:\n%v\nGot:\n%v\n", ec, ret)
-	}
-}
-
 func Test_GenerateEnvironmentalControllerEndpoints(t *testing.T) {
 	ret := GenerateEnvironmentalControllerEndpoints(3)
 
@@ -110,7 +81,7 @@ func Test_GenerateEnvironmentalControllerEndpoints(t *testing.T) {
 }
 
 func Test_GenerateNodeCardEndpoints(t *testing.T) {
-	ret := GenerateNodeCardEndpoints("02", 7, 5, 3)
+	ret := GenerateNodeCardEndpoints(topologies["mountain"], macaddr.DefaultScheme, "02", 7, 5, 3)
 
 	nc2 := NetEndpoint{
 		name:   "x7c5s3b1",
@@ -124,7 +95,7 @@ func Test_GenerateNodeCardEndpoints(t *testing.T) {
 }
 
 func Test_GenerateSwitchCardEndpoints(t *testing.T) {
-	ret := GenerateSwitchCardEndpoints("02", 7, 5)
+	ret := GenerateSwitchCardEndpoints(topologies["mountain"], macaddr.DefaultScheme, "02", 7, 5)
 
 	sc1 := NetEndpoint{
 		name:   "x7c5r0b0",
@@ -136,13 +107,13 @@ func Test_GenerateSwitchCardEndpoints(t *testing.T) {
 		t.Errorf("Switch card did not match exectation:\nExpectation:\n%v\nGot:\n%v\n", sc1, *(ret[0]))
 	}
 
-	if len(ret) != MTN_SWITCH_COUNT*(1+MTN_nC_PER_SLOT) {
-		t.Errorf("Endpoints is the wrong length.  Is: %d, should be %d", len(ret), MTN_SWITCH_COUNT*(1+MTN_nC_PER_SLOT))
+	if len(ret) != MTN_SWITCH_COUNT {
+		t.Errorf("Endpoints is the wrong length.  Is: %d, should be %d", len(ret), MTN_SWITCH_COUNT)
 	}
 }
 
 func Test_GenerateChassisEndpoints(t *testing.T) {
-	ret := GenerateChassisEndpoints("02", 7, []int{0, 1, 2, 3, 4, 5, 6, 7})
+	ret := GenerateChassisEndpoints(topologies["mountain"], macaddr.DefaultScheme, "02", 7, []int{0, 1, 2, 3, 4, 5, 6, 7})
 
 	cha1 := NetEndpoint{
 		name:   "x7c0b0",
@@ -154,8 +125,8 @@ func Test_GenerateChassisEndpoints(t *testing.T) {
 		t.Errorf("Chassis did not match exectation:\nExpectation:\n%v\nGot:\n%v\n", cha1, *(ret[0]))
 	}
 
-	if len(ret) != MTN_CHASSIS_COUNT*(1+MTN_SWITCH_COUNT*(1+MTN_nC_PER_SLOT)) {
-		t.Errorf("Endpoints is the wrong length.  Is: %d, should be %d", len(ret), MTN_CHASSIS_COUNT*(1+MTN_SWITCH_COUNT*(1+MTN_nC_PER_SLOT)))
+	if len(ret) != MTN_CHASSIS_COUNT*(1+MTN_SWITCH_COUNT+MTN_SLOT_COUNT*MTN_nC_PER_SLOT) {
+		t.Errorf("Endpoints is the wrong length.  Is: %d, should be %d", len(ret), MTN_CHASSIS_COUNT*(1+MTN_SWITCH_COUNT+MTN_SLOT_COUNT*MTN_nC_PER_SLOT))
 	}
 }
 
@@ -204,7 +175,7 @@ func configure_queryNet(resp HSMEndpointPresence, respAddr *string, eresp *error
 	queryNet_count = 0
 }
 
-func mock_queryNet(ne NetEndpoint) (HSMEndpointPresence, *string, *error) {
+func mock_queryNet(ctx context.Context, ne *NetEndpoint) (HSMEndpointPresence, *string, *error) {
 	queryNet_count += 1
 	return queryNet_response, queryNet_respAddr, queryNet_error
 }
@@ -217,8 +188,8 @@ func configure_notifyHSMPresent(err *error) {
 	notifyHSMPresentCalls = make([]NetEndpoint, 0)
 }
 
-func mock_notifyHSMPresent(xname NetEndpoint, addr string) *error {
-	notifyHSMPresentCalls = append(notifyHSMPresentCalls, xname)
+func mock_notifyHSMPresent(ctx context.Context, xname *NetEndpoint, addr string) *error {
+	notifyHSMPresentCalls = append(notifyHSMPresentCalls, *xname)
 	return notifyHSMPresentResponse
 }
 
@@ -230,7 +201,7 @@ func configure_notifyHSMNotPresent(err *error) {
 	notifyHSMNotPresentCalls = make([]NetEndpoint, 0)
 }
 
-func mock_notifyHSMNotPresent(xname NetEndpoint) *error {
+func mock_notifyHSMNotPresent(ctx context.Context, xname NetEndpoint) *error {
 	notifyHSMNotPresentCalls = append(notifyHSMNotPresentCalls, xname)
 	return notifyHSMNotPresentResponse
 }
@@ -613,6 +584,22 @@ func Test_notifyHSMXnamePresent(t *testing.T) {
 			hwtype: TYPE_NODE_CARD,
 		},
 		true,
+	}, {
+		"CEC with an address from SLS",
+		map[string]HTTPResponse{
+			"/Inventory/RedfishEndpoints": HTTPResponse{
+				201,
+				`[{"URI": "/hsm/v2/Inventory/RedfishEndpoints/x1000e1"}]`,
+				json.RawMessage(`{"ID":"x1000e1","FQDN":"10.254.0.5","MACAddr":"02:03:E8:FF:01:00","RediscoverOnUpdate":true}`),
+			},
+		},
+		NetEndpoint{
+			name:    "x1000e1",
+			mac:     "02:03:E8:FF:01:00",
+			address: "10.254.0.5",
+			hwtype:  TYPE_ENV_CONTROLLER,
+		},
+		false,
 	}}
 
 	serviceName = "MEDS_TEST"
@@ -648,7 +635,7 @@ func Test_notifyHSMXnamePresent(t *testing.T) {
 		defer testServer.Close()
 		hsm = testServer.URL
 
-		err := (notifyHSMXnamePresent(test.nodeIn, "10.0.0.1"))
+		err := (notifyHSMXnamePresent(context.Background(), test.nodeIn, "10.0.0.1"))
 		if !test.expectErr {
 			if err != nil {
 				t.Errorf("Test %v (%s) Failed: Received unexpected error - %v", i, test.description, err)
@@ -676,6 +663,15 @@ func Test_queryHSMState(t *testing.T) {
 		"/Inventory/RedfishEndpoints",
 		PRESENCE_PRESENT,
 		false,
+	}, {
+		"Success (200) from HSM, endpoint marked absent by MEDS.",
+		200,
+		map[string]*NetEndpoint{
+			"x7c5s3b1": &NetEndpoint{name: "x7c5s3b1", HSMPresence: PRESENCE_NOT_PRESENT, locallyAbsent: true},
+		},
+		"/Inventory/RedfishEndpoints",
+		PRESENCE_NOT_PRESENT,
+		false,
 	}, {
 		"Not found (404) from HSM.",
 		404,
@@ -725,7 +721,7 @@ func Test_queryHSMState(t *testing.T) {
 		responseCode = test.respCode
 		requestURI = ""
 		activeEndpoints = test.epsIn
-		err := (queryHSMState())
+		err := (queryHSMState(context.Background()))
 		if !test.expectErr {
 			if err != nil {
 				t.Errorf("Test %v (%s) Failed: Received unexpected error - %v", i, test.description, err)
@@ -747,23 +743,38 @@ func Test_queryNetworkStatus(t *testing.T) {
 	tests := []struct {
 		description      string
 		respCode         int
+		respBody         string
 		expectedReqURI   string
 		expectedPresence HSMEndpointPresence
+		expectedResult   string
 		expectErr        bool
 	}{{
 		"Success (200). Present",
 		200,
+		testServiceRoot,
 		"/redfish/v1/",
 		PRESENCE_PRESENT,
+		PROBE_PRESENT,
 		false,
 	}, {
 		"Error (400). Not present",
 		400,
+		`{"type":"about:blank","detail":"Detail about this specific problem occurrence. See RFC7807","instance":"","status":500,"title":"Description of HTTP Status code, e.g. 500"}`,
+		"/redfish/v1/",
+		PRESENCE_NOT_PRESENT,
+		PROBE_BAD_STATUS,
+		true,
+	}, {
+		"Success (200) from something else. Not present",
+		200,
+		`{"ID":"x0c0s0b0n0","Type":"Node"}`,
 		"/redfish/v1/",
 		PRESENCE_NOT_PRESENT,
+		PROBE_WRONG_DEVICE,
 		true,
 	}}
 	var responseCode int
+	var responseBody string
 	var requestURI string
 	serviceName = "MEDS_TEST"
 	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -775,13 +786,9 @@ func Test_queryNetworkStatus(t *testing.T) {
 			}
 
 			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(responseCode)
-			// The response payload doesn't really matter since queryNetworkStatus() doesn't parse it.
-			w.Write(json.RawMessage(`{"ID":"x0c0s0b0n0","Type":"Node"}`))
-		} else {
-			w.WriteHeader(responseCode)
-			w.Write(json.RawMessage(`{"type":"about:blank","detail":"Detail about this specific problem occurrence. See RFC7807","instance":"","status":500,"title":"Description of HTTP Status code, e.g. 500"}`))
 		}
+		w.WriteHeader(responseCode)
+		w.Write(json.RawMessage(responseBody))
 	}))
 	defer testServer.Close()
 	strs := strings.Split(testServer.URL, "//")
@@ -797,11 +804,15 @@ func Test_queryNetworkStatus(t *testing.T) {
 
 	for i, test := range tests {
 		responseCode = test.respCode
+		responseBody = test.respBody
 		requestURI = ""
-		isPresent, _, err := queryNetworkStatus(endpoint)
+		isPresent, _, err := queryNetworkStatus(context.Background(), &endpoint)
 		if isPresent != test.expectedPresence {
 			t.Errorf("Test %v (%s) Failed: Expected component presence is '%v'; Received '%v'", i, test.description, HSMEndpointPresenceToString[test.expectedPresence], HSMEndpointPresenceToString[isPresent])
 		}
+		if info := endpoint.serviceRoot; info.Result != test.expectedResult {
+			t.Errorf("Test %v (%s) Failed: Expected probe result '%s'; Received '%s'", i, test.description, test.expectedResult, info.Result)
+		}
 		if !test.expectErr {
 			if err != nil {
 				t.Errorf("Test %v (%s) Failed: Received unexpected error - %v", i, test.description, *err)
@@ -1100,7 +1111,7 @@ func Test_verifyCabinetRedfishEndpoints(t *testing.T) {
 		hsmRedfishEndpointsCacheLock = sync.Mutex{}
 		hsmRedfishEndpointsCache = test.redfishEndpointsCache
 
-		err := (verifyCabinetRedfishEndpoints(test.netEndpoints))
+		err := (verifyCabinetRedfishEndpoints(context.Background(), test.netEndpoints))
 		if !test.expectErr {
 			if err != nil {
 				t.Errorf("Test %v (%s) Failed: Received unexpected error - %v", i, test.description, err)
@@ -1110,3 +1121,16 @@ func Test_verifyCabinetRedfishEndpoints(t *testing.T) {
 		}
 	}
 }
+
+func Test_patchXName_badRequest(t *testing.T) {
+	defer func(old string) { hsm = old }(hsm)
+	// An HSM URL a request can't be made for.
+	hsm = "http://cray-smd\x7f"
+
+	if err := patchXNameEnabled(context.Background(), "x1000c0s0b0", true); err == nil {
+		t.Errorf("patchXNameEnabled: Expected an error")
+	}
+	if err := patchXnameFQDN(context.Background(), "x1000c0s0b0", "x1000c0s0b0.hmn", "x1000c0s0b0"); err == nil {
+		t.Errorf("patchXnameFQDN: Expected an error")
+	}
+}
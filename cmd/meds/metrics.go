@@ -0,0 +1,132 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	endpointsGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "meds_endpoints",
+		Help: "Number of endpoints tracked by MEDS, by hardware type and HSM presence.",
+	}, []string{"type", "presence"})
+	redfishPingDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "meds_redfish_ping_duration_seconds",
+		Help:    "Latency of Redfish pings, by result.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"result"})
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "meds_requests_total",
+		Help: "Requests made by MEDS to other services, by service, operation and status code (or 'ok'/'error' where there is none).",
+	}, []string{"service", "operation", "code"})
+	hsmLastSyncGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "meds_hsm_last_sync_timestamp_seconds",
+		Help: "Unix time of the last successful sync with HSM.",
+	})
+	probeEndpointsGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "meds_probe_endpoints",
+		Help: "Number of endpoints scheduled for Redfish pings.",
+	})
+	probesInFlightGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "meds_probes_in_flight",
+		Help: "Number of Redfish pings in flight.",
+	})
+	geolocationMismatches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "meds_geolocation_mismatches_total",
+		Help: "BMCs whose reported MAC address or location disagreed with their xname, by check.",
+	}, []string{"check"})
+	networkProtocolDrift = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "meds_network_protocol_drift_total",
+		Help: "BMC NetworkProtocol settings found to differ from what MEDS pushes, by setting.",
+	}, []string{"setting"})
+	credentialCheckResults = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "meds_credential_checks_total",
+		Help: "Checks of BMC Vault credentials before registering with HSM, by result.",
+	}, []string{"result"})
+	credentialRotations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "meds_credential_rotations_total",
+		Help: "BMC credential rotations, by result.",
+	}, []string{"result"})
+	reinitializations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "meds_reinitializations_total",
+		Help: "Present endpoints MEDS initialized again, by reason.",
+	}, []string{"reason"})
+
+	metricsHandler = promhttp.Handler()
+)
+
+// recordRequest counts the outcome of an HTTP request to HSM, SLS, etc.
+func recordRequest(service, operation string, resp *http.Response, err error) {
+	code := "error"
+	if err == nil && resp != nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	requestsTotal.WithLabelValues(service, operation, code).Inc()
+}
+
+// recordResult counts the outcome of a request with no HTTP status code
+// available to us, such as a Vault lookup.
+func recordResult(service, operation string, err error) {
+	if err != nil {
+		requestsTotal.WithLabelValues(service, operation, "error").Inc()
+	} else {
+		requestsTotal.WithLabelValues(service, operation, "ok").Inc()
+	}
+}
+
+// updateEndpointsGauge recomputes the per-type/per-presence endpoint counts.
+func updateEndpointsGauge() {
+	activeEndpointsLock.Lock()
+	endpoints := make([]*NetEndpoint, 0, len(activeEndpoints))
+	for _, ne := range activeEndpoints {
+		endpoints = append(endpoints, ne)
+	}
+	activeEndpointsLock.Unlock()
+
+	counts := make(map[[2]string]int)
+	for _, ne := range endpoints {
+		ne.HSMPresLock.Lock()
+		key := [2]string{EndpointTypeToString[ne.hwtype], HSMEndpointPresenceToString[ne.HSMPresence]}
+		ne.HSMPresLock.Unlock()
+		counts[key]++
+	}
+
+	endpointsGauge.Reset()
+	for key, count := range counts {
+		endpointsGauge.WithLabelValues(key[0], key[1]).Set(float64(count))
+	}
+}
+
+// GET /metrics
+func doMetricsGet(w http.ResponseWriter, r *http.Request) {
+	updateEndpointsGauge()
+	metricsHandler.ServeHTTP(w, r)
+}
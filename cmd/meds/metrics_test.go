@@ -0,0 +1,61 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_doMetricsGet(t *testing.T) {
+	setupAPITestEndpoints()
+	recordRequest("hsm", "post_redfish_endpoint", &http.Response{StatusCode: 409}, nil)
+	recordResult("vault", "store_comp_cred", errors.New("dummy"))
+	redfishPingDuration.WithLabelValues(PROBE_PRESENT).Observe(0.1)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	newRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status code: %d", w.Code)
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{
+		`meds_endpoints{presence="present",type="Chassis"} 1`,
+		`meds_endpoints{presence="not present",type="Node Card"} 1`,
+		`meds_requests_total{code="409",operation="post_redfish_endpoint",service="hsm"}`,
+		`meds_requests_total{code="error",operation="store_comp_cred",service="vault"}`,
+		`# TYPE meds_redfish_ping_duration_seconds histogram`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Metrics output is missing '%s':\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// The steps of initializing an endpoint that has appeared.  Credentials
+// must be stored in Vault before anything else is done, since HSM pulls
+// them from there.
+const (
+	STEP_CREDENTIALS      = "Credentials"
+	STEP_GEOLOCATION      = "Geolocation"
+	STEP_NETWORK_PROTOCOL = "NetworkProtocol"
+	STEP_HSM              = "HSM"
+)
+
+// The results of an initialization step.
+const (
+	STEP_OK      = "ok"
+	STEP_FAILED  = "failed"
+	STEP_SKIPPED = "skipped"
+)
+
+// How many more times an initialization that failed at a step that may
+// succeed on another attempt is retried early, and how long the endpoint
+// waits for its next ping before the first retry.  The wait doubles after
+// each retry; after the last the endpoint goes back to being pinged every
+// checkupFixedWait seconds.
+var initRetries = 2
+var initRetryWait = 2 * time.Second
+
+// StepStatus is the result of one step of initializing an endpoint.
+// Attempts counts the consecutive initializations that ran the step.
+type StepStatus struct {
+	Step     string `json:"Step"`
+	Result   string `json:"Result"`
+	Attempts int    `json:"Attempts,omitempty"`
+	Error    string `json:"Error,omitempty"`
+	retry    bool   // The step may succeed on another attempt
+}
+
+// InitStatus is the result of the last attempt to initialize an endpoint.
+// The credential sources are the Vault keys the endpoint's credentials and
+// SSH keys came from, or "defaults".
+type InitStatus struct {
+	StartedAt           time.Time    `json:"StartedAt"`
+	FinishedAt          time.Time    `json:"FinishedAt"`
+	Success             bool         `json:"Success"`
+	CredentialSource    string       `json:"CredentialSource,omitempty"`
+	SSHCredentialSource string       `json:"SSHCredentialSource,omitempty"`
+	Steps               []StepStatus `json:"Steps"`
+}
+
+// initPipeline runs the steps of initializing one endpoint in order and
+// records how each went.  The caller must hold ne.HSMPresLock.
+type initPipeline struct {
+	ne     *NetEndpoint
+	xname  string
+	status InitStatus
+}
+
+func newInitPipeline(ne *NetEndpoint) *initPipeline {
+	return &initPipeline{
+		ne:     ne,
+		xname:  ne.name,
+		status: InitStatus{StartedAt: time.Now(), Steps: make([]StepStatus, 0)},
+	}
+}
+
+// run runs a step and records the result.  retry says whether the step
+// may succeed if the initialization is tried again.  Nothing is retried
+// here, as the endpoint is locked while it is initialized; see
+// initRetryDelay.
+func (p *initPipeline) run(step string, retry bool, f func() error) error {
+	status := StepStatus{Step: step, Result: STEP_OK, Attempts: 1, retry: retry}
+	for _, prev := range p.ne.initStatus.Steps {
+		if prev.Step == step && prev.Result == STEP_FAILED {
+			status.Attempts = prev.Attempts + 1
+		}
+	}
+
+	err := f()
+	if err != nil {
+		log.Printf("WARNING: %s step of initializing %s failed (attempt %d): %v",
+			step, p.xname, status.Attempts, err)
+		status.Result = STEP_FAILED
+		status.Error = err.Error()
+	}
+	p.status.Steps = append(p.status.Steps, status)
+	return err
+}
+
+// skip records steps that weren't run.
+func (p *initPipeline) skip(steps ...string) {
+	for _, step := range steps {
+		p.status.Steps = append(p.status.Steps, StepStatus{Step: step, Result: STEP_SKIPPED})
+	}
+}
+
+// finish records the result of the initialization and returns an error
+// naming the steps that failed, if any did.
+func (p *initPipeline) finish() *error {
+	p.status.FinishedAt = time.Now()
+	failed := make([]string, 0)
+	for _, step := range p.status.Steps {
+		if step.Result == STEP_FAILED {
+			failed = append(failed, step.Step+": "+step.Error)
+		}
+	}
+	p.status.Success = len(failed) == 0
+
+	p.ne.initStatus = p.status
+
+	if len(failed) == 0 {
+		return nil
+	}
+	err := fmt.Errorf("%s", strings.Join(failed, "; "))
+	return &err
+}
+
+// initRetryDelay is how long to wait before pinging an endpoint whose
+// initialization failed, so that it is initialized again: initRetryWait,
+// doubling with each attempt, until a step that may succeed on another
+// attempt has been retried initRetries times.  It is 0, meaning the usual
+// wait, for anything else.
+func initRetryDelay(status InitStatus) time.Duration {
+	if status.Success {
+		return 0
+	}
+	for _, step := range status.Steps {
+		if step.Result == STEP_FAILED && step.retry && step.Attempts <= initRetries {
+			return initRetryWait << (step.Attempts - 1)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,283 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/hms-certs/pkg/hms_certs"
+	compcreds "github.com/Cray-HPE/hms-compcredentials"
+
+	"github.com/Cray-HPE/hms-meds/internal/model"
+	mtest "github.com/Cray-HPE/hms-meds/internal/testing"
+)
+
+// flakyStore is a Vault that fails the first failures stores.
+type flakyStore struct {
+	*mtest.KvMock
+	failures int
+}
+
+func (f *flakyStore) Store(key string, value interface{}) error {
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("vault is sealed")
+	}
+	return f.KvMock.Store(key, value)
+}
+
+func Test_initPipeline_run(t *testing.T) {
+	tests := []struct {
+		failures       int
+		retry          bool
+		expectAttempts int
+		expectResult   string
+		expectDelays   []time.Duration
+	}{
+		{0, true, 1, STEP_OK, nil},
+		{1, true, 2, STEP_OK, []time.Duration{initRetryWait}},
+		{initRetries, true, initRetries + 1, STEP_OK, []time.Duration{initRetryWait, 2 * initRetryWait}},
+		{initRetries + 1, true, initRetries + 1, STEP_FAILED, []time.Duration{initRetryWait, 2 * initRetryWait, 0}},
+		{1, false, 1, STEP_FAILED, []time.Duration{0}},
+	}
+
+	for i, test := range tests {
+		// Each initialization is a single attempt; the scheduler pings the
+		// endpoint again after the delay to retry.
+		ne := &NetEndpoint{name: "x1000c0s0b0"}
+		failures := test.failures
+		var delays []time.Duration
+		var step StepStatus
+		var err error
+		for {
+			p := newInitPipeline(ne)
+			err = p.run(STEP_HSM, test.retry, func() error {
+				if failures > 0 {
+					failures--
+					return errors.New("HSM is down")
+				}
+				return nil
+			})
+			p.finish()
+			step = ne.initStatus.Steps[0]
+			if err == nil {
+				break
+			}
+			delay := initRetryDelay(ne.initStatus)
+			delays = append(delays, delay)
+			if delay == 0 {
+				break
+			}
+		}
+		if step.Attempts != test.expectAttempts || step.Result != test.expectResult ||
+			(err != nil) != (test.expectResult == STEP_FAILED) {
+			t.Errorf("Test %v Failed: Expected %v attempts, result %s; Received %+v (%v)",
+				i, test.expectAttempts, test.expectResult, step, err)
+		}
+		if len(delays) != len(test.expectDelays) {
+			t.Errorf("Test %v Failed: Expected retry delays %v; Received %v", i, test.expectDelays, delays)
+			continue
+		}
+		for j := range delays {
+			if delays[j] != test.expectDelays[j] {
+				t.Errorf("Test %v Failed: Expected retry delays %v; Received %v", i, test.expectDelays, delays)
+				break
+			}
+		}
+	}
+}
+
+func Test_notifyXnamePresent_pipeline(t *testing.T) {
+	posts := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer testServer.Close()
+	hsm = testServer.URL
+	defUser = "root"
+	defPass = "initial0"
+	client, _ = hms_certs.CreateHTTPClientPair("", clientTimeout)
+
+	tests := []struct {
+		description      string
+		storeFailures    int
+		expectSuccess    bool
+		expectPosts      int
+		expectAttempts   int
+		expectCredResult string
+		expectHSMResult  string
+	}{
+		{"Credentials stored", 0, true, 1, 1, STEP_OK, STEP_OK},
+		{"Vault store retried", 1, true, 1, 2, STEP_OK, STEP_OK},
+		{"Vault store fails", initRetries + 1, false, 0, initRetries + 1, STEP_FAILED, STEP_SKIPPED},
+	}
+
+	for i, test := range tests {
+		ss := &flakyStore{KvMock: mtest.NewKvMock(), failures: test.storeFailures}
+		hcs = compcreds.NewCompCredStore("hms-creds", ss)
+		credStorage = model.NewMedsCredStore(model.CredentialsKeyPrefix, ss)
+		posts = 0
+		node := NetEndpoint{
+			name:    "x1000e1",
+			address: "10.254.0.5",
+			hwtype:  TYPE_ENV_CONTROLLER,
+		}
+
+		// Initialize again for as long as the scheduler would retry early.
+		var err *error
+		for {
+			err = notifyXnamePresent(context.Background(), &node, node.address)
+			if err == nil || initRetryDelay(node.initStatus) == 0 {
+				break
+			}
+		}
+		if (err == nil) != test.expectSuccess {
+			t.Errorf("Test %v (%s) Failed: Expected success %v; Received %v", i, test.description, test.expectSuccess, err)
+		}
+		if posts != test.expectPosts {
+			t.Errorf("Test %v (%s) Failed: Expected %v HSM POSTs; Received %v",
+				i, test.description, test.expectPosts, posts)
+		}
+
+		status := node.initStatus
+		if status.Success != test.expectSuccess || len(status.Steps) != 2 {
+			t.Errorf("Test %v (%s) Failed: Unexpected initialization status %+v", i, test.description, status)
+			continue
+		}
+		if status.Steps[0].Step != STEP_CREDENTIALS || status.Steps[0].Result != test.expectCredResult ||
+			status.Steps[0].Attempts != test.expectAttempts {
+			t.Errorf("Test %v (%s) Failed: Unexpected credentials step %+v", i, test.description, status.Steps[0])
+		}
+		if status.Steps[1].Step != STEP_HSM || status.Steps[1].Result != test.expectHSMResult {
+			t.Errorf("Test %v (%s) Failed: Unexpected HSM step %+v", i, test.description, status.Steps[1])
+		}
+	}
+}
+
+func Test_notifyXnamePresent_networkProtocolFails(t *testing.T) {
+	defer func() {
+		geolocationCheck = GEOLOCATION_WARN
+		credentialCheck = CREDENTIAL_CHECK_WARN
+	}()
+	geolocationCheck = GEOLOCATION_OFF
+	credentialCheck = CREDENTIAL_CHECK_OFF
+
+	posts := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer testServer.Close()
+	hsm = testServer.URL
+	defUser = "root"
+	defPass = "initial0"
+	client, _ = hms_certs.CreateHTTPClientPair("", clientTimeout)
+	ss := mtest.NewKvMock()
+	hcs = compcreds.NewCompCredStore("hms-creds", ss)
+	credStorage = model.NewMedsCredStore(model.CredentialsKeyPrefix, ss)
+
+	// Nothing answers at the BMC's address, so its NetworkProtocol can't
+	// be set.
+	node := NetEndpoint{name: "x1000c0s0b0", address: "127.0.0.1:1", hwtype: TYPE_NODE_CARD}
+	err := notifyXnamePresent(context.Background(), &node, node.address)
+	if err == nil {
+		t.Fatalf("Expected initialization to fail")
+	}
+	if posts != 0 {
+		t.Errorf("Expected the BMC not to be registered with HSM; Received %d POSTs", posts)
+	}
+	results := make(map[string]string)
+	for _, step := range node.initStatus.Steps {
+		results[step.Step] = step.Result
+	}
+	if results[STEP_NETWORK_PROTOCOL] != STEP_FAILED || results[STEP_HSM] != STEP_SKIPPED {
+		t.Errorf("Expected NetworkProtocol to fail and HSM to be skipped; Received %+v", node.initStatus.Steps)
+	}
+	if initRetryDelay(node.initStatus) != initRetryWait {
+		t.Errorf("Expected the initialization to be retried after %v; Received %v",
+			initRetryWait, initRetryDelay(node.initStatus))
+	}
+}
+
+func Test_seedCredentials(t *testing.T) {
+	oldUser, oldPass := defUser, defPass
+	defer func() { defUser, defPass = oldUser, oldPass }()
+
+	tests := []struct {
+		description    string
+		xnameCreds     bool
+		chassisCreds   bool
+		defaults       bool
+		expectErr      bool
+		expectPassword string
+		expectSource   string
+	}{
+		{"Per-xname credentials", true, true, true, false, "xname", "hms-creds/x1000c3s5b0"},
+		{"Chassis credentials", false, true, true, false, "chassis",
+			model.CredentialsKeyPrefix + "/" + model.CredentialsBMCKey + "/x1000c3"},
+		{"Defaults", false, false, true, false, "initial0", CREDENTIAL_SOURCE_DEFAULTS},
+		{"Nothing", false, false, false, true, "", ""},
+	}
+
+	for i, test := range tests {
+		ss := mtest.NewKvMock()
+		hcs = compcreds.NewCompCredStore("hms-creds", ss)
+		credStorage = model.NewMedsCredStore(model.CredentialsKeyPrefix, ss)
+		if test.xnameCreds {
+			hcs.StoreCompCred(compcreds.CompCredentials{Xname: "x1000c3s5b0", Username: "root", Password: "xname"})
+		}
+		if test.chassisCreds {
+			credStorage.StoreBMCCredentials("x1000c3", model.MedsCredentials{Username: "root", Password: "chassis"})
+		}
+		defUser, defPass = "", ""
+		if test.defaults {
+			defUser, defPass = "root", "initial0"
+		}
+
+		cred, source, err := seedCredentials("x1000c3s5b0")
+		if (err != nil) != test.expectErr {
+			t.Errorf("Test %v (%s) Failed: Expected error %v; Received %v", i, test.description, test.expectErr, err)
+			continue
+		}
+		if test.expectErr {
+			continue
+		}
+		if cred.Password != test.expectPassword || source != test.expectSource {
+			t.Errorf("Test %v (%s) Failed: Expected password %q from %s; Received %q from %s",
+				i, test.description, test.expectPassword, test.expectSource, cred.Password, source)
+		}
+		stored, _ := hcs.GetCompCred("x1000c3s5b0")
+		if stored.Password != test.expectPassword {
+			t.Errorf("Test %v (%s) Failed: Expected %q stored in Vault; Received %q",
+				i, test.description, test.expectPassword, stored.Password)
+		}
+	}
+}
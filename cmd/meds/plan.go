@@ -0,0 +1,542 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	sls_common "github.com/Cray-HPE/hms-sls/v2/pkg/sls-common"
+	"github.com/Cray-HPE/hms-smd/v2/pkg/sm"
+	"github.com/Cray-HPE/hms-xname/xnames"
+	"github.com/Cray-HPE/hms-xname/xnametypes"
+
+	"github.com/Cray-HPE/hms-meds/internal/macaddr"
+)
+
+// Whether to only report what MEDS would change (see -dry-run).
+var dryRun = false
+
+// MAC scheme for each class of cabinet that doesn't name one in SLS, as
+// given by -mac-schemes and as parsed.
+var macSchemes = ""
+var macSchemesByClass = map[string]string{}
+
+// EthernetInterfaceAction is a planned write of a generated MAC address to
+// HSM's EthernetInterfaces.  Action is POST for a new interface, or PATCH
+// if HSM has the MAC address under a different component.
+type EthernetInterfaceAction struct {
+	Action        string `json:"Action"`
+	MACAddr       string `json:"MACAddr"`
+	CompID        string `json:"CompID"`
+	CurrentCompID string `json:"CurrentCompID,omitempty"`
+}
+
+// EthernetInterfaceMismatch is an EthernetInterface in HSM whose CompID
+// isn't the xname encoded in its MEDS-generated MAC address.
+type EthernetInterfaceMismatch struct {
+	MACAddr      string `json:"MACAddr"`
+	CompID       string `json:"CompID"`
+	EncodedXname string `json:"EncodedXname"`
+}
+
+// FQDNFix is a planned correction of a ChassisBMC RedfishEndpoint's FQDN
+// and hostname in HSM.
+type FQDNFix struct {
+	Xname       string `json:"Xname"`
+	CurrentFQDN string `json:"CurrentFQDN"`
+	FQDN        string `json:"FQDN"`
+	Hostname    string `json:"Hostname"`
+}
+
+// RedfishEndpointAction is a planned write of a RedfishEndpoint to HSM for
+// an endpoint that answers on the network.  Action is POST for a new
+// RedfishEndpoint, or PATCH to enable a disabled one.
+type RedfishEndpointAction struct {
+	Xname   string `json:"Xname"`
+	Action  string `json:"Action"`
+	Address string `json:"Address"`
+}
+
+// ChassisPlan is everything MEDS would change to start managing a chassis,
+// or the CECs of a cabinet.
+type ChassisPlan struct {
+	Xname                        string                      `json:"Xname"`
+	Error                        string                      `json:"Error,omitempty"`
+	EthernetInterfaces           []EthernetInterfaceAction   `json:"EthernetInterfaces"`
+	MismatchedEthernetInterfaces []EthernetInterfaceMismatch `json:"MismatchedEthernetInterfaces"`
+	FQDNFixes                    []FQDNFix                   `json:"FQDNFixes"`
+	RedfishEndpoints             []RedfishEndpointAction     `json:"RedfishEndpoints"`
+	NetworkProtocol              []string                    `json:"NetworkProtocol"`
+	Unreachable                  []string                    `json:"Unreachable"`
+}
+
+type Plan struct {
+	Chassis []ChassisPlan `json:"Chassis"`
+}
+
+// chassisEndpoints generates the endpoints MEDS should look for in a
+// chassis.  If the chassis' inventory from SLS is given, only the
+// positions SLS has are included.
+func chassisEndpoints(cabinet, chassis sls_common.GenericHardware, inventory *ChassisInventory) ([]*NetEndpoint, error) {
+	//
+	// Parse the chassis xname
+	//
+	chassisXnameRaw := xnames.FromString(chassis.Xname)
+	if chassisXnameRaw == nil {
+		return nil, fmt.Errorf("INTERNAL ERROR, unable to parse chassis xname '%v'", chassis.Xname)
+	}
+
+	chassisXname, ok := chassisXnameRaw.(xnames.Chassis)
+	if !ok {
+		return nil, fmt.Errorf("INTERNAL ERROR, chassis xname strcture for '%v' is of type '%T' expected 'xnames.Chassis'", chassis.Xname, chassis)
+	}
+
+	if chassisXname.Parent().String() != cabinet.Xname {
+		return nil, fmt.Errorf("unable to initialize chassis, provided cabinet (%v) is not the parent of provided chassis (%v)",
+			cabinet.Xname, chassis.Parent)
+	}
+
+	macPrefix, scheme, err := cabinetMACSettings(cabinet)
+	if err != nil {
+		return nil, err
+	}
+
+	topology, err := chassisTopology(cabinet, chassis)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate the list of endpoints that MEDS should look for contained within in this chassis.
+	endpoints := make([]*NetEndpoint, 0)
+	// CECs belong to the cabinet; see cabinetCECEndpoints.
+	endpoints = append(endpoints, GenerateChassisEndpoints(topology, scheme, macPrefix, chassisXname.Cabinet, []int{chassisXname.Chassis})...)
+	return inventory.filter(endpoints), nil
+}
+
+// cabinetMACSettings returns the prefix and scheme of the MAC addresses
+// MEDS generates for a cabinet's BMCs.  The scheme is named by a
+// "MACScheme" in the cabinet's ExtraProperties in SLS, or else by
+// macSchemesByClass.
+func cabinetMACSettings(cabinet sls_common.GenericHardware) (string, macaddr.MACScheme, error) {
+	//
+	// Extract cabinet specific overrides from SLS
+	//
+	var cabExtra struct {
+		sls_common.ComptypeCabinet
+		MACScheme string `json:"MACScheme,omitempty"`
+	}
+	ce, baerr := json.Marshal(cabinet.ExtraPropertiesRaw)
+	if baerr != nil {
+		err := fmt.Errorf("INTERNAL ERROR, can't marshal cab props: %v",
+			baerr)
+		log.Println(err)
+		return "", nil, err
+	}
+
+	baerr = json.Unmarshal(ce, &cabExtra)
+	if baerr != nil {
+		err := fmt.Errorf("INTERNAL ERROR, can't unmarshal cab props: %v",
+			baerr)
+		log.Println(err)
+		return "", nil, err
+	}
+
+	// Make sure the map checks out before reaching into it to avoid panic.
+	hmnNetwork, networkExists := cabExtra.Networks["cn"]["HMN"]
+	if !networkExists {
+		err := fmt.Errorf("cabinet doesn't have HMN network for compute nodes: %+v", cabExtra)
+		return "", nil, err
+	}
+
+	macPrefix := ""
+	if hmnNetwork.MACPrefix != "" {
+		macPrefix = hmnNetwork.MACPrefix
+	} else {
+		// Default
+		macPrefix = MAC_PREFIX
+	}
+
+	schemeName := cabExtra.MACScheme
+	if schemeName == "" {
+		schemeName = macSchemesByClass[string(cabinet.Class)]
+	}
+	if schemeName == "" {
+		schemeName = macaddr.DefaultSchemeName
+	}
+	scheme, ok := macaddr.GetMACScheme(schemeName)
+	if !ok {
+		return "", nil, fmt.Errorf("cabinet %s uses unknown MAC scheme '%s'", cabinet.Xname, schemeName)
+	}
+	return macPrefix, scheme, nil
+}
+
+// parseMACSchemes parses a comma separated list of class=scheme pairs,
+// such as "Hill=default", checking that each scheme exists.
+func parseMACSchemes(spec string) (map[string]string, error) {
+	byClass := make(map[string]string)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		class, name, found := strings.Cut(pair, "=")
+		if !found || class == "" {
+			return nil, fmt.Errorf("'%s' is not of the form class=scheme", pair)
+		}
+		if _, ok := macaddr.GetMACScheme(name); !ok {
+			return nil, fmt.Errorf("unknown MAC scheme '%s' (known schemes: %s)",
+				name, strings.Join(macaddr.MACSchemeNames(), ", "))
+		}
+		byClass[class] = name
+	}
+	return byClass, nil
+}
+
+// checkMACCollisions makes sure none of the endpoints' generated MAC
+// addresses is shared with another of them or with an endpoint that is
+// already tracked.
+func checkMACCollisions(endpoints []*NetEndpoint, tracked map[string]*NetEndpoint) error {
+	owners := make(map[string]string)
+	for _, ne := range tracked {
+		if ne.mac != "" {
+			owners[macaddr.Normalize(ne.mac)] = ne.name
+		}
+	}
+
+	collisions := make([]string, 0)
+	for _, ne := range endpoints {
+		if ne.mac == "" {
+			continue
+		}
+		mac := macaddr.Normalize(ne.mac)
+		if owner, ok := owners[mac]; ok && owner != ne.name {
+			collisions = append(collisions, fmt.Sprintf("%s and %s both have MAC address %s", owner, ne.name, ne.mac))
+			continue
+		}
+		owners[mac] = ne.name
+	}
+	if len(collisions) > 0 {
+		return fmt.Errorf("generated MAC addresses collide: %s", strings.Join(collisions, "; "))
+	}
+	return nil
+}
+
+// findMismatchedEthernetInterfaces finds EthernetInterfaces in HSM with
+// MEDS-generated MAC addresses for BMCs in the chassis whose CompID
+// disagrees with the xname encoded in the MAC address.
+func findMismatchedEthernetInterfaces(scheme macaddr.MACScheme, prefix, chassis string, hsmEthernetInterfaces []sm.CompEthInterfaceV2) []EthernetInterfaceMismatch {
+	mismatches := make([]EthernetInterfaceMismatch, 0)
+	for _, ei := range hsmEthernetInterfaces {
+		xname, _, err := scheme.Parse(prefix, ei.MACAddr)
+		if err != nil || !xnamesRelated(chassis, xname) {
+			// Not one of ours, or not in this chassis
+			continue
+		}
+		if ei.CompID != "" && ei.CompID != xname {
+			mismatches = append(mismatches, EthernetInterfaceMismatch{
+				MACAddr:      macaddr.Normalize(ei.MACAddr),
+				CompID:       ei.CompID,
+				EncodedXname: xname,
+			})
+		}
+	}
+	sort.Slice(mismatches, func(i, j int) bool {
+		return mismatches[i].MACAddr < mismatches[j].MACAddr
+	})
+	return mismatches
+}
+
+// planEthernetInterfaces works out which of the endpoints' generated MAC
+// addresses need to be added to or corrected in HSM.
+func planEthernetInterfaces(endpoints []*NetEndpoint, hsmEthernetInterfaces []sm.CompEthInterfaceV2) []EthernetInterfaceAction {
+	hsmEthernetInterfacesMap := map[string]sm.CompEthInterfaceV2{}
+	for _, ei := range hsmEthernetInterfaces {
+		hsmEthernetInterfacesMap[ei.ID] = ei
+	}
+
+	actions := make([]EthernetInterfaceAction, 0)
+	for _, v := range endpoints {
+		normalizedMAC := strings.ToLower(strings.ReplaceAll(v.mac, ":", ""))
+
+		// Check to see if the generated endpoint has a MAC address associated with it.
+		// Currently MEDS does generate a MAC addresses fro CEC's. Ex: x5000e0, x5000e1
+		if normalizedMAC == "" {
+			log.Printf("WARN: Endpoint has no MAC address: %s", v.name)
+			continue
+		}
+
+		hsmEI, ok := hsmEthernetInterfacesMap[normalizedMAC]
+		if ok && hsmEI.CompID == v.name {
+			// The MAC address is currently in HSM with the same component ID
+			log.Printf("INFO: Ethernet interface for MAC %s and CompID %s already present in HSM", normalizedMAC, v.name)
+			continue
+		}
+
+		action := EthernetInterfaceAction{
+			Action:  http.MethodPost,
+			MACAddr: normalizedMAC,
+			CompID:  v.name,
+		}
+		if ok {
+			// The MAC address is currently in HSM with a different component ID
+			action.Action = http.MethodPatch
+			action.CurrentCompID = hsmEI.CompID
+		}
+		actions = append(actions, action)
+	}
+	return actions
+}
+
+// planFQDNFixes finds ChassisBMC RedfishEndpoints in HSM whose FQDN isn't
+// their xname.
+func planFQDNFixes(endpoints []*NetEndpoint, rfEPs map[string]HSMNotification) []FQDNFix {
+	fixes := make([]FQDNFix, 0)
+	for _, v := range endpoints {
+		rfEP, known := rfEPs[v.name]
+		if !known {
+			continue
+		}
+
+		// Verify that ChassisBMC's have the correct FQDN/hostname values set
+		// TODO For authoritative DNS the following check should be changed to handle the FQDN of the system.
+		if xnametypes.GetHMSType(rfEP.ID) == xnametypes.ChassisBMC && rfEP.ID != rfEP.FQDN {
+			fixes = append(fixes, FQDNFix{
+				Xname:       v.name,
+				CurrentFQDN: rfEP.FQDN,
+				FQDN:        rfEP.ID,
+				Hostname:    rfEP.ID,
+			})
+		}
+	}
+	return fixes
+}
+
+// planChassis works out everything MEDS would change for a chassis,
+// pinging each of its endpoints with netQuery.  Nothing is written.  The
+// chassis' endpoints are added to tracked unless their MAC addresses
+// collide with those already there.
+func planChassis(
+	ctx context.Context,
+	cabinet, chassis sls_common.GenericHardware,
+	inventory *ChassisInventory,
+	hsmEthernetInterfaces []sm.CompEthInterfaceV2,
+	rfEPs map[string]HSMNotification,
+	tracked map[string]*NetEndpoint,
+	netQuery func(context.Context, *NetEndpoint) (HSMEndpointPresence, *string, *error)) ChassisPlan {
+
+	plan := ChassisPlan{
+		Xname:            chassis.Xname,
+		RedfishEndpoints: make([]RedfishEndpointAction, 0),
+		NetworkProtocol:  make([]string, 0),
+		Unreachable:      make([]string, 0),
+	}
+
+	endpoints, err := chassisEndpoints(cabinet, chassis, inventory)
+	if err == nil {
+		err = checkMACCollisions(endpoints, tracked)
+	}
+	if err != nil {
+		plan.Error = err.Error()
+		return plan
+	}
+	for _, ne := range endpoints {
+		tracked[ne.name] = ne
+	}
+	plan.EthernetInterfaces = planEthernetInterfaces(endpoints, hsmEthernetInterfaces)
+	macPrefix, scheme, _ := cabinetMACSettings(cabinet)
+	plan.MismatchedEthernetInterfaces = findMismatchedEthernetInterfaces(scheme, macPrefix, chassis.Xname, hsmEthernetInterfaces)
+	plan.FQDNFixes = planFQDNFixes(endpoints, rfEPs)
+	planRedfishEndpoints(ctx, &plan, endpoints, rfEPs, netQuery)
+	return plan
+}
+
+// planRedfishEndpoints pings endpoints with netQuery and adds the
+// RedfishEndpoints and NetworkProtocol settings MEDS would write for those
+// that answer to plan.
+func planRedfishEndpoints(
+	ctx context.Context,
+	plan *ChassisPlan,
+	endpoints []*NetEndpoint,
+	rfEPs map[string]HSMNotification,
+	netQuery func(context.Context, *NetEndpoint) (HSMEndpointPresence, *string, *error)) {
+
+	// Ping every endpoint, at most probeWorkers at a time.
+	addresses := make([]*string, len(endpoints))
+	sem := make(chan struct{}, max(probeWorkers, 1))
+	var wg sync.WaitGroup
+	for i, ne := range endpoints {
+		wg.Add(1)
+		go func(i int, ne *NetEndpoint) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			presence, addr, _ := netQuery(ctx, ne)
+			if presence == PRESENCE_PRESENT && addr != nil {
+				addresses[i] = addr
+			}
+		}(i, ne)
+	}
+	wg.Wait()
+
+	for i, ne := range endpoints {
+		if addresses[i] == nil {
+			plan.Unreachable = append(plan.Unreachable, ne.name)
+			continue
+		}
+
+		// Endpoints that are enabled in HSM are left alone; anything else
+		// gets the full initialization once it answers.
+		action := RedfishEndpointAction{Xname: ne.name, Address: *addresses[i]}
+		rfEP, known := rfEPs[ne.name]
+		if !known {
+			action.Action = http.MethodPost
+		} else if rfEP.Enabled != nil && !*rfEP.Enabled {
+			action.Action = http.MethodPatch
+		} else {
+			continue
+		}
+		plan.RedfishEndpoints = append(plan.RedfishEndpoints, action)
+		if ne.hwtype != TYPE_ENV_CONTROLLER {
+			plan.NetworkProtocol = append(plan.NetworkProtocol, ne.name)
+		}
+	}
+	sort.Strings(plan.Unreachable)
+	sort.Slice(plan.RedfishEndpoints, func(i, j int) bool {
+		return plan.RedfishEndpoints[i].Xname < plan.RedfishEndpoints[j].Xname
+	})
+	sort.Strings(plan.NetworkProtocol)
+}
+
+// planCabinetCECs works out everything MEDS would change for the CECs of a
+// cabinet, like planChassis.
+func planCabinetCECs(
+	ctx context.Context,
+	cabinet sls_common.GenericHardware,
+	cecs []sls_common.GenericHardware,
+	hsmEthernetInterfaces []sm.CompEthInterfaceV2,
+	rfEPs map[string]HSMNotification,
+	tracked map[string]*NetEndpoint,
+	netQuery func(context.Context, *NetEndpoint) (HSMEndpointPresence, *string, *error)) ChassisPlan {
+
+	plan := ChassisPlan{
+		Xname:            cabinet.Xname,
+		RedfishEndpoints: make([]RedfishEndpointAction, 0),
+		NetworkProtocol:  make([]string, 0),
+		Unreachable:      make([]string, 0),
+	}
+
+	endpoints, err := cabinetCECEndpoints(cabinet, cecs)
+	if err == nil {
+		err = checkMACCollisions(endpoints, tracked)
+	}
+	if err != nil {
+		plan.Error = err.Error()
+		return plan
+	}
+	for _, ne := range endpoints {
+		tracked[ne.name] = ne
+	}
+	plan.EthernetInterfaces = planEthernetInterfaces(endpoints, hsmEthernetInterfaces)
+	planRedfishEndpoints(ctx, &plan, endpoints, rfEPs, netQuery)
+	return plan
+}
+
+// buildPlan reads SLS and HSM and pings every endpoint to work out what
+// MEDS would change, without writing anything.
+func buildPlan(ctx context.Context,
+	netQuery func(context.Context, *NetEndpoint) (HSMEndpointPresence, *string, *error)) (Plan, error) {
+
+	plan := Plan{Chassis: make([]ChassisPlan, 0)}
+
+	cabinets, err := getSLSCabInfo(ctx)
+	if err != nil {
+		return plan, fmt.Errorf("unable to get cabinet list from SLS: %v", err)
+	}
+
+	rfEPs, err := getHSMRedfishEndpoints(ctx)
+	if err != nil {
+		return plan, fmt.Errorf("unable to get RedfishEndpoints from HSM: %v", err)
+	}
+
+	hsmEthernetInterfaces, err := dhcpdnsClient.GetAllEthernetInterfaces()
+	recordResult("hsm", "get_ethernet_interfaces", err)
+	if err != nil {
+		return plan, fmt.Errorf("unable to get EthernetInterfaces from HSM: %v", err)
+	}
+
+	var inventory map[string]*ChassisInventory
+	if slsInventory {
+		inventory, err = getSLSChassisInventory(ctx)
+		if err != nil {
+			return plan, err
+		}
+	}
+
+	tracked := make(map[string]*NetEndpoint)
+	for _, cabinet := range cabinets {
+		cabinetChassis, err := getSLSCabinetChassis(ctx, cabinet.Xname)
+		if err != nil {
+			return plan, fmt.Errorf("unable to get chassis of '%s' from SLS: %v", cabinet.Xname, err)
+		}
+		if cecDiscovery {
+			cecs, err := getSLSHardwareOfType(ctx, sls_common.CEC, cabinet.Xname)
+			if err != nil {
+				return plan, fmt.Errorf("unable to get CECs of '%s' from SLS: %v", cabinet.Xname, err)
+			}
+			log.Printf("INFO: Planning CECs of cabinet %s", cabinet.Xname)
+			plan.Chassis = append(plan.Chassis,
+				planCabinetCECs(ctx, cabinet, cecs, hsmEthernetInterfaces, rfEPs, tracked, netQuery))
+		}
+		for _, chassis := range cabinetChassis {
+			log.Printf("INFO: Planning chassis %s", chassis.Xname)
+			plan.Chassis = append(plan.Chassis,
+				planChassis(ctx, cabinet, chassis, inventory[chassis.Xname], hsmEthernetInterfaces, rfEPs, tracked, netQuery))
+		}
+	}
+
+	sort.Slice(plan.Chassis, func(i, j int) bool {
+		return plan.Chassis[i].Xname < plan.Chassis[j].Xname
+	})
+	return plan, nil
+}
+
+// runDryRun writes the plan as JSON to w.
+func runDryRun(ctx context.Context, w io.Writer) error {
+	plan, err := buildPlan(ctx, queryNetworkStatus)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(plan)
+}
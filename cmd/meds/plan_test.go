@@ -0,0 +1,374 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/hms-certs/pkg/hms_certs"
+	dns_dhcp "github.com/Cray-HPE/hms-dns-dhcp/pkg"
+	sls_common "github.com/Cray-HPE/hms-sls/v2/pkg/sls-common"
+	"github.com/Cray-HPE/hms-smd/v2/pkg/sm"
+
+	"github.com/Cray-HPE/hms-meds/internal/macaddr"
+)
+
+// A MAC address scheme that differs from the default only in name, so the
+// tests can tell which scheme a cabinet gets.
+type renamedScheme struct {
+	macaddr.MACScheme
+}
+
+func (renamedScheme) Name() string { return "renamed" }
+
+func init() {
+	macaddr.RegisterMACScheme(renamedScheme{macaddr.DefaultScheme})
+}
+
+var testPlanCabinet = sls_common.GenericHardware{
+	Xname: "x9000",
+	Class: sls_common.ClassMountain,
+	ExtraPropertiesRaw: sls_common.ComptypeCabinet{
+		Networks: map[string]map[string]sls_common.CabinetNetworks{
+			"cn": {"HMN": {CIDR: "10.104.0.1/22"}},
+		},
+	},
+}
+
+var testPlanChassis = sls_common.GenericHardware{
+	Parent: "x9000",
+	Xname:  "x9000c1",
+}
+
+// mockPlanNetQuery answers for the given endpoints only.
+func mockPlanNetQuery(present ...string) func(context.Context, *NetEndpoint) (HSMEndpointPresence, *string, *error) {
+	return func(ctx context.Context, ne *NetEndpoint) (HSMEndpointPresence, *string, *error) {
+		for _, name := range present {
+			if ne.name == name {
+				addr := ne.name
+				return PRESENCE_PRESENT, &addr, nil
+			}
+		}
+		return PRESENCE_NOT_PRESENT, nil, nil
+	}
+}
+
+func Test_planChassis(t *testing.T) {
+	endpoints, err := chassisEndpoints(testPlanCabinet, testPlanChassis, nil)
+	if err != nil {
+		t.Fatalf("Unable to generate chassis endpoints: %v", err)
+	}
+	allEthernetInterfaces := make([]sm.CompEthInterfaceV2, 0, len(endpoints))
+	for _, ne := range endpoints {
+		mac := strings.ReplaceAll(ne.mac, ":", "")
+		allEthernetInterfaces = append(allEthernetInterfaces,
+			sm.CompEthInterfaceV2{ID: mac, MACAddr: mac, CompID: ne.name})
+	}
+	movedEthernetInterface := allEthernetInterfaces[0]
+	movedEthernetInterface.CompID = "x9000c7b0"
+	disabled := false
+
+	tests := []struct {
+		description        string
+		cabinet            sls_common.GenericHardware
+		ethernetInterfaces []sm.CompEthInterfaceV2
+		rfEPs              map[string]HSMNotification
+		present            []string
+		expectPOSTs        int
+		expectPATCHes      int
+		expectRFActions    []RedfishEndpointAction
+		expectFQDNFixes    int
+		expectError        bool
+	}{{
+		description:        "New chassis, chassis BMC answers",
+		cabinet:            testPlanCabinet,
+		ethernetInterfaces: []sm.CompEthInterfaceV2{},
+		rfEPs:              map[string]HSMNotification{},
+		present:            []string{"x9000c1b0"},
+		expectPOSTs:        len(endpoints),
+		expectRFActions:    []RedfishEndpointAction{{"x9000c1b0", http.MethodPost, "x9000c1b0"}},
+	}, {
+		description:        "Known chassis, nothing to do",
+		cabinet:            testPlanCabinet,
+		ethernetInterfaces: allEthernetInterfaces,
+		rfEPs: map[string]HSMNotification{
+			"x9000c1b0": {ID: "x9000c1b0", FQDN: "x9000c1b0"},
+		},
+		present:         []string{"x9000c1b0"},
+		expectRFActions: []RedfishEndpointAction{},
+	}, {
+		description:        "Moved MAC, disabled endpoint and stale FQDN",
+		cabinet:            testPlanCabinet,
+		ethernetInterfaces: append([]sm.CompEthInterfaceV2{movedEthernetInterface}, allEthernetInterfaces[1:]...),
+		rfEPs: map[string]HSMNotification{
+			"x9000c1b0":   {ID: "x9000c1b0", FQDN: "x9000c1b0.local"},
+			"x9000c1s0b0": {ID: "x9000c1s0b0", FQDN: "x9000c1s0b0", Enabled: &disabled},
+		},
+		present:       []string{"x9000c1s0b0", "x9000c1s0b1"},
+		expectPATCHes: 1,
+		expectRFActions: []RedfishEndpointAction{
+			{"x9000c1s0b0", http.MethodPatch, "x9000c1s0b0"},
+			{"x9000c1s0b1", http.MethodPost, "x9000c1s0b1"},
+		},
+		expectFQDNFixes: 1,
+	}, {
+		description: "Cabinet without an HMN network",
+		cabinet: sls_common.GenericHardware{
+			Xname:              "x9000",
+			ExtraPropertiesRaw: sls_common.ComptypeCabinet{},
+		},
+		expectError: true,
+	}}
+
+	for i, test := range tests {
+		plan := planChassis(context.Background(), test.cabinet, testPlanChassis, nil,
+			test.ethernetInterfaces, test.rfEPs, map[string]*NetEndpoint{}, mockPlanNetQuery(test.present...))
+
+		if test.expectError {
+			if plan.Error == "" {
+				t.Errorf("Test %v (%s) Failed: Expected an error", i, test.description)
+			}
+			continue
+		}
+		if plan.Error != "" {
+			t.Errorf("Test %v (%s) Failed: Unexpected error: %s", i, test.description, plan.Error)
+			continue
+		}
+
+		posts, patches := 0, 0
+		for _, action := range plan.EthernetInterfaces {
+			if action.Action == http.MethodPatch {
+				patches++
+			} else {
+				posts++
+			}
+		}
+		if posts != test.expectPOSTs || patches != test.expectPATCHes {
+			t.Errorf("Test %v (%s) Failed: Expected %d EthernetInterface POSTs and %d PATCHes; Received %d and %d",
+				i, test.description, test.expectPOSTs, test.expectPATCHes, posts, patches)
+		}
+		if len(plan.RedfishEndpoints) != len(test.expectRFActions) {
+			t.Errorf("Test %v (%s) Failed: Expected RedfishEndpoint actions %+v; Received %+v",
+				i, test.description, test.expectRFActions, plan.RedfishEndpoints)
+		} else {
+			for j, action := range test.expectRFActions {
+				if plan.RedfishEndpoints[j] != action {
+					t.Errorf("Test %v (%s) Failed: Expected RedfishEndpoint action %+v; Received %+v",
+						i, test.description, action, plan.RedfishEndpoints[j])
+				}
+				if plan.NetworkProtocol[j] != action.Xname {
+					t.Errorf("Test %v (%s) Failed: Expected NetworkProtocol PATCH of %s; Received %s",
+						i, test.description, action.Xname, plan.NetworkProtocol[j])
+				}
+			}
+		}
+		if len(plan.FQDNFixes) != test.expectFQDNFixes {
+			t.Errorf("Test %v (%s) Failed: Expected %d FQDN fixes; Received %+v",
+				i, test.description, test.expectFQDNFixes, plan.FQDNFixes)
+		}
+		if len(plan.Unreachable) != len(endpoints)-len(test.present) {
+			t.Errorf("Test %v (%s) Failed: Expected %d unreachable endpoints; Received %d",
+				i, test.description, len(endpoints)-len(test.present), len(plan.Unreachable))
+		}
+	}
+}
+
+func Test_buildPlan_readOnly(t *testing.T) {
+	serviceName = "MEDS_TEST"
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Dry run made a write: %s %s", r.Method, r.URL.String())
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var payload interface{}
+		switch {
+		case r.URL.Path == "/search/hardware" && r.URL.Query().Get("type") == "comptype_cabinet":
+			if r.URL.Query().Get("class") == "Mountain" {
+				payload = []sls_common.GenericHardware{testPlanCabinet}
+			} else {
+				payload = []sls_common.GenericHardware{}
+			}
+		case r.URL.Path == "/search/hardware":
+			payload = []sls_common.GenericHardware{testPlanChassis}
+		case r.URL.Path == "/Inventory/RedfishEndpoints":
+			payload = HSMNotificationArray{}
+		case r.URL.Path == "/hsm/v2/Inventory/EthernetInterfaces":
+			payload = []sm.CompEthInterfaceV2{}
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.String())
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(payload)
+	}))
+	defer testServer.Close()
+
+	hsm = testServer.URL
+	sls = testServer.URL
+	client, _ = hms_certs.CreateHTTPClientPair("", clientTimeout)
+	dhcpdnsClient = dns_dhcp.NewDHCPDNSHelperInstance(testServer.URL, nil, serviceName)
+
+	plan, err := buildPlan(context.Background(), mockPlanNetQuery("x9000c1b0"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(plan.Chassis) != 1 || plan.Chassis[0].Xname != "x9000c1" {
+		t.Fatalf("Expected a plan for x9000c1; Received %+v", plan)
+	}
+	if len(plan.Chassis[0].RedfishEndpoints) != 1 || len(plan.Chassis[0].NetworkProtocol) != 1 {
+		t.Errorf("Expected x9000c1b0 to be added; Received %+v", plan.Chassis[0])
+	}
+}
+
+func Test_findMismatchedEthernetInterfaces(t *testing.T) {
+	ethernetInterfaces := []sm.CompEthInterfaceV2{
+		// Correct
+		{ID: "022328010000", MACAddr: "022328010000", CompID: "x9000c1b0"},
+		// Node BMC MAC assigned to its neighbour
+		{ID: "022328013310", MACAddr: "02:23:28:01:33:10", CompID: "x9000c1s3b0"},
+		// No CompID yet
+		{ID: "022328016100", MACAddr: "022328016100"},
+		// Other chassis
+		{ID: "022328020000", MACAddr: "022328020000", CompID: "x9000c1b0"},
+		// Not a MEDS MAC address
+		{ID: "b42e99a61234", MACAddr: "b42e99a61234", CompID: "x9000c1s0b0n0"},
+	}
+
+	mismatches := findMismatchedEthernetInterfaces(macaddr.DefaultScheme, "02", "x9000c1", ethernetInterfaces)
+	expected := EthernetInterfaceMismatch{MACAddr: "022328013310", CompID: "x9000c1s3b0", EncodedXname: "x9000c1s3b1"}
+	if len(mismatches) != 1 || mismatches[0] != expected {
+		t.Errorf("Expected %+v; Received %+v", expected, mismatches)
+	}
+}
+
+func Test_cabinetMACSettings(t *testing.T) {
+	defer func() { macSchemesByClass = map[string]string{} }()
+	macSchemesByClass = map[string]string{"Hill": "renamed"}
+
+	cabinet := func(class sls_common.CabinetType, extra map[string]interface{}) sls_common.GenericHardware {
+		extra["Networks"] = map[string]interface{}{
+			"cn": map[string]interface{}{"HMN": map[string]interface{}{"CIDR": "10.104.0.1/22", "MACPrefix": "0e"}},
+		}
+		return sls_common.GenericHardware{Xname: "x9000", Class: class, ExtraPropertiesRaw: extra}
+	}
+
+	tests := []struct {
+		description  string
+		cabinet      sls_common.GenericHardware
+		expectScheme string
+		expectErr    bool
+	}{{
+		"Mountain cabinet",
+		cabinet(sls_common.ClassMountain, map[string]interface{}{}),
+		macaddr.DefaultSchemeName,
+		false,
+	}, {
+		"Hill cabinet",
+		cabinet(sls_common.ClassHill, map[string]interface{}{}),
+		"renamed",
+		false,
+	}, {
+		"Scheme named in SLS",
+		cabinet(sls_common.ClassHill, map[string]interface{}{"MACScheme": "default"}),
+		macaddr.DefaultSchemeName,
+		false,
+	}, {
+		"Unknown scheme named in SLS",
+		cabinet(sls_common.ClassMountain, map[string]interface{}{"MACScheme": "bogus"}),
+		"",
+		true,
+	}}
+
+	for i, test := range tests {
+		prefix, scheme, err := cabinetMACSettings(test.cabinet)
+		if test.expectErr {
+			if err == nil {
+				t.Errorf("Test %v (%s) Failed: Expected an error", i, test.description)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %v (%s) Failed: Unexpected error: %v", i, test.description, err)
+		} else if prefix != "0e" || scheme.Name() != test.expectScheme {
+			t.Errorf("Test %v (%s) Failed: Expected prefix 0e and scheme %s; Received %s and %s",
+				i, test.description, test.expectScheme, prefix, scheme.Name())
+		}
+	}
+}
+
+func Test_parseMACSchemes(t *testing.T) {
+	byClass, err := parseMACSchemes("Hill=renamed, Mountain=default")
+	if err != nil || byClass["Hill"] != "renamed" || byClass["Mountain"] != "default" {
+		t.Errorf("Unexpected result %v (%v)", byClass, err)
+	}
+	for _, spec := range []string{"Hill", "Hill=bogus", "=renamed"} {
+		if _, err := parseMACSchemes(spec); err == nil {
+			t.Errorf("Expected an error for '%s'", spec)
+		}
+	}
+}
+
+func Test_checkMACCollisions(t *testing.T) {
+	tracked := map[string]*NetEndpoint{
+		"x9000c0b0": {name: "x9000c0b0", mac: "02:23:28:00:00:00"},
+	}
+
+	tests := []struct {
+		description string
+		endpoints   []*NetEndpoint
+		expectErr   bool
+	}{{
+		"No collisions",
+		[]*NetEndpoint{{name: "x9000c1b0", mac: "02:23:28:01:00:00"}, {name: "x9000e0"}, {name: "x9000e1"}},
+		false,
+	}, {
+		"Already tracked endpoint regenerated",
+		[]*NetEndpoint{{name: "x9000c0b0", mac: "02:23:28:00:00:00"}},
+		false,
+	}, {
+		"Collision with a tracked endpoint",
+		[]*NetEndpoint{{name: "x9001c0b0", mac: "022328000000"}},
+		true,
+	}, {
+		"Collision within the new endpoints",
+		[]*NetEndpoint{{name: "x9000c1b0", mac: "02:23:28:01:00:00"}, {name: "x9000c1r0b0", mac: "02:23:28:01:00:00"}},
+		true,
+	}}
+
+	for i, test := range tests {
+		err := checkMACCollisions(test.endpoints, tracked)
+		if (err != nil) != test.expectErr {
+			t.Errorf("Test %v (%s) Failed: Expected error %v; Received %v", i, test.description, test.expectErr, err)
+		}
+	}
+}
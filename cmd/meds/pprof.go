@@ -33,8 +33,9 @@ import (
 	_ "net/http/pprof"
 )
 
-// Because MEDS does not start an HTTP server we need to create one when
-// pprof is enabled.
+// The pprof handlers register themselves on http.DefaultServeMux, which the
+// MEDS API server does not use, so serve them on their own port to keep them
+// off the API listener.
 func PProfInit() {
 	log.Printf("Starting pprof HTTP server")
 
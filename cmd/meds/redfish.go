@@ -0,0 +1,186 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+)
+
+// The parts of Redfish resources MEDS reads from BMCs.
+
+type RedfishLink struct {
+	OdataID string `json:"@odata.id"`
+}
+
+type RedfishServiceRoot struct {
+	OdataType      string       `json:"@odata.type"`
+	RedfishVersion string       `json:"RedfishVersion"`
+	UUID           string       `json:"UUID"`
+	Vendor         string       `json:"Vendor"`
+	Product        string       `json:"Product"`
+	Managers       *RedfishLink `json:"Managers"`
+	Chassis        *RedfishLink `json:"Chassis"`
+}
+
+type RedfishAccount struct {
+	OdataID  string `json:"@odata.id"`
+	ID       string `json:"Id"`
+	UserName string `json:"UserName"`
+}
+
+type RedfishCollection struct {
+	Members []RedfishLink `json:"Members"`
+}
+
+type RedfishManager struct {
+	ID                 string      `json:"Id"`
+	EthernetInterfaces RedfishLink `json:"EthernetInterfaces"`
+	Links              struct {
+		ManagerForChassis []RedfishLink `json:"ManagerForChassis"`
+	} `json:"Links"`
+}
+
+type RedfishEthernetInterface struct {
+	ID                  string `json:"Id"`
+	MACAddress          string `json:"MACAddress"`
+	PermanentMACAddress string `json:"PermanentMACAddress"`
+}
+
+type RedfishChassis struct {
+	ID       string `json:"Id"`
+	Location struct {
+		PartLocation struct {
+			ServiceLabel string `json:"ServiceLabel"`
+		} `json:"PartLocation"`
+	} `json:"Location"`
+}
+
+// RedfishStatusError is returned when a BMC answers a Redfish request with
+// an unexpected status.
+type RedfishStatusError struct {
+	Message    string
+	StatusCode int
+	Body       string
+}
+
+func (e *RedfishStatusError) Error() string {
+	return fmt.Sprintf("%s returned %d: %s", e.Message, e.StatusCode, e.Body)
+}
+
+// isAuthFailure says whether a Redfish request failed because the BMC
+// didn't accept the credentials.
+func isAuthFailure(err error) bool {
+	var statusErr *RedfishStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden
+	}
+	return false
+}
+
+// redfishGet does an authenticated GET of a Redfish resource on the BMC at
+// address and unmarshals it into v.  operation names the request in the
+// meds_requests_total metric.
+func redfishGet(ctx context.Context, address, path, user, pass, operation string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+address+path, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(user, pass)
+
+	rfClientLock.RLock()
+	resp, err := rfClient.Do(req)
+	rfClientLock.RUnlock()
+	defer base.DrainAndCloseResponseBody(resp)
+	recordRequest("redfish", operation, resp, err)
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	if resp.Body != nil {
+		body, err = ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &RedfishStatusError{
+			Message:    fmt.Sprintf("GET %s from %s", path, address),
+			StatusCode: resp.StatusCode,
+			Body:       string(body),
+		}
+	}
+	err = json.Unmarshal(body, v)
+	if err != nil {
+		return fmt.Errorf("unable to unmarshal %s from %s: %v", path, address, err)
+	}
+	return nil
+}
+
+// redfishPatch does an authenticated PATCH of a Redfish resource on the BMC
+// at address with v as the body.  operation names the request in the
+// meds_requests_total metric.
+func redfishPatch(ctx context.Context, address, path, user, pass, operation string, v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, "https://"+address+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(user, pass)
+
+	rfClientLock.RLock()
+	resp, err := rfClient.Do(req)
+	rfClientLock.RUnlock()
+	defer base.DrainAndCloseResponseBody(resp)
+	recordRequest("redfish", operation, resp, err)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		var body []byte
+		if resp.Body != nil {
+			body, _ = ioutil.ReadAll(resp.Body)
+		}
+		return &RedfishStatusError{
+			Message:    fmt.Sprintf("PATCH %s on %s", path, address),
+			StatusCode: resp.StatusCode,
+			Body:       string(body),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log"
+	"math/big"
+	"path"
+
+	"github.com/Cray-HPE/hms-meds/internal/model"
+)
+
+// Length of the passwords MEDS generates when rotating BMC credentials.
+const ROTATION_PASSWORD_LENGTH = 16
+
+const rotationPasswordChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// If true, the API allows the credentials of BMCs to be rotated.
+var credentialRotation = false
+
+// RotationResult is the outcome of rotating the credentials of one BMC.
+// RolledBack is set if the BMC's password was changed and then changed
+// back because the rotation couldn't be completed.  If it couldn't be
+// changed back either, the new password is kept in Vault under
+// SavedPasswordKey, or returned as UnsavedPassword if even that failed, so
+// that the BMC isn't left with a password nobody knows.
+type RotationResult struct {
+	Xname            string `json:"Xname"`
+	Success          bool   `json:"Success"`
+	RolledBack       bool   `json:"RolledBack,omitempty"`
+	SavedPasswordKey string `json:"SavedPasswordKey,omitempty"`
+	UnsavedPassword  string `json:"UnsavedPassword,omitempty"`
+	Error            string `json:"Error,omitempty"`
+}
+
+type RotationResultArray struct {
+	Results []RotationResult `json:"Results"`
+}
+
+// This is swapped out by the unit tests.
+var rotateCredentials = rotateBMCPassword
+
+// generatePassword returns a random password of length characters.
+func generatePassword(length int) (string, error) {
+	password := make([]byte, length)
+	max := big.NewInt(int64(len(rotationPasswordChars)))
+	for i := range password {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		password[i] = rotationPasswordChars[n.Int64()]
+	}
+	return string(password), nil
+}
+
+// findAccount returns the path of the BMC account with the given user
+// name.
+func findAccount(ctx context.Context, address, user, pass string) (string, error) {
+	var accounts RedfishCollection
+	err := redfishGet(ctx, address, "/redfish/v1/AccountService/Accounts", user, pass, "get_accounts", &accounts)
+	if err != nil {
+		return "", err
+	}
+	for _, member := range accounts.Members {
+		var account RedfishAccount
+		err = redfishGet(ctx, address, member.OdataID, user, pass, "get_account", &account)
+		if err != nil {
+			return "", err
+		}
+		if account.UserName == user {
+			return member.OdataID, nil
+		}
+	}
+	return "", fmt.Errorf("no account for user %s on %s", user, address)
+}
+
+// rollbackPassword changes a BMC account's password back after a failed
+// rotation.  If the new password doesn't work the change may never have
+// taken, which is fine as long as the old password still works.
+func rollbackPassword(ctx context.Context, address, accountPath, user, oldPass, newPass string) error {
+	err := redfishPatch(ctx, address, accountPath, user, newPass, "patch_account",
+		map[string]string{"Password": oldPass})
+	if err == nil {
+		return nil
+	}
+	var account RedfishAccount
+	if redfishGet(ctx, address, accountPath, user, oldPass, "get_account", &account) == nil {
+		return nil
+	}
+	return err
+}
+
+// rotateBMCPassword changes the password of a BMC's account through its
+// Redfish AccountService, using the credentials in Vault, checks that the
+// new password works and stores it in Vault.  If the new password can't
+// be verified or stored, the old one is put back.
+func rotateBMCPassword(ctx context.Context, xname, address, newPassword string) RotationResult {
+	result := RotationResult{Xname: xname}
+
+	cred, err := hcs.GetCompCred(xname)
+	recordResult("vault", "get_comp_cred", err)
+	if err == nil && (cred.Username == "" || cred.Password == "") {
+		err = fmt.Errorf("no credentials in Vault")
+	}
+	if err != nil {
+		result.Error = fmt.Sprintf("unable to get current credentials: %v", err)
+		return result
+	}
+	if newPassword == cred.Password {
+		result.Error = "new password is the same as the current one"
+		return result
+	}
+
+	accountPath, err := findAccount(ctx, address, cred.Username, cred.Password)
+	if err != nil {
+		result.Error = fmt.Sprintf("unable to find BMC account: %v", err)
+		return result
+	}
+
+	// A PATCH that fails, e.g. by timing out, may still have been applied,
+	// so whether the new password works decides what happened.
+	patchErr := redfishPatch(ctx, address, accountPath, cred.Username, cred.Password, "patch_account",
+		map[string]string{"Password": newPassword})
+
+	var account RedfishAccount
+	err = redfishGet(ctx, address, accountPath, cred.Username, newPassword, "get_account", &account)
+	if err != nil && patchErr != nil {
+		result.Error = fmt.Sprintf("unable to change password: %v", patchErr)
+		if redfishGet(ctx, address, accountPath, cred.Username, cred.Password, "get_account", &account) != nil {
+			log.Printf("ERROR: Neither the old nor the new password of %s works after a failed rotation", xname)
+			result.Error += "; neither the old nor the new password works"
+		}
+		return result
+	}
+	if err != nil {
+		err = fmt.Errorf("new password doesn't work: %v", err)
+	} else {
+		if patchErr != nil {
+			log.Printf("WARNING: Changing the password of %s failed (%v), but the new password works", xname, patchErr)
+		}
+		newCred := cred
+		newCred.Password = newPassword
+		err = hcs.StoreCompCred(newCred)
+		recordResult("vault", "store_comp_cred", err)
+		if err != nil {
+			err = fmt.Errorf("unable to store new credentials in Vault: %v", err)
+		}
+	}
+	if err != nil {
+		result.Error = err.Error()
+
+		// The BMC may have the new password from now on, so keep it until
+		// the old one is back.
+		newCred := model.MedsCredentials{Username: cred.Username, Password: newPassword}
+		serr := credStorage.StoreRotationCredentials(xname, newCred)
+		recordResult("vault", "store_rotation_creds", serr)
+
+		rerr := rollbackPassword(ctx, address, accountPath, cred.Username, cred.Password, newPassword)
+		if rerr == nil {
+			result.RolledBack = true
+			if serr == nil {
+				credStorage.DeleteRotationCredentials(xname)
+			}
+			return result
+		}
+		log.Printf("ERROR: Unable to restore the password of %s after a failed rotation: %v", xname, rerr)
+		result.Error += fmt.Sprintf("; unable to restore the old password: %v", rerr)
+		if serr == nil {
+			result.SavedPasswordKey = path.Join(credStorage.CCPath, model.CredentialsRotationKey, xname)
+			log.Printf("ERROR: The password %s may now have is in Vault at %s", xname, result.SavedPasswordKey)
+		} else {
+			log.Printf("ERROR: Unable to save the password %s may now have in Vault: %v", xname, serr)
+			result.UnsavedPassword = newPassword
+		}
+		return result
+	}
+
+	result.Success = true
+	return result
+}
+
+// rotateEndpoint rotates the credentials of a tracked BMC to a generated
+// password.  The endpoint isn't pinged or initialized while this runs.
+func rotateEndpoint(ctx context.Context, ne *NetEndpoint) RotationResult {
+	ne.HSMPresLock.Lock()
+	defer ne.HSMPresLock.Unlock()
+
+	result := RotationResult{Xname: ne.name}
+	if ne.hwtype == TYPE_ENV_CONTROLLER {
+		result.Error = "credential rotation is only supported for BMCs"
+		return result
+	}
+	if ne.HSMPresence != PRESENCE_PRESENT || ne.lastPingPresence != PRESENCE_PRESENT || ne.lastPing.IsZero() {
+		result.Error = "endpoint is not present"
+		return result
+	}
+
+	password, err := generatePassword(ROTATION_PASSWORD_LENGTH)
+	if err != nil {
+		result.Error = fmt.Sprintf("unable to generate a password: %v", err)
+		return result
+	}
+
+	address := ne.name
+	if ne.address != "" {
+		address = ne.address
+	}
+
+	log.Printf("INFO: Rotating credentials of %s", ne.name)
+	result = rotateCredentials(ctx, ne.name, address, password)
+	switch {
+	case result.Success:
+		credentialRotations.WithLabelValues("rotated").Inc()
+		log.Printf("INFO: Rotated credentials of %s", ne.name)
+	case result.RolledBack:
+		credentialRotations.WithLabelValues("rolled_back").Inc()
+		log.Printf("WARNING: Rotation of credentials of %s failed and was rolled back: %s", ne.name, result.Error)
+	default:
+		credentialRotations.WithLabelValues("failed").Inc()
+		log.Printf("WARNING: Rotation of credentials of %s failed: %s", ne.name, result.Error)
+	}
+	return result
+}
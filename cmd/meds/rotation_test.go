@@ -0,0 +1,337 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/hms-certs/pkg/hms_certs"
+	compcreds "github.com/Cray-HPE/hms-compcredentials"
+
+	"github.com/Cray-HPE/hms-meds/internal/model"
+	mtest "github.com/Cray-HPE/hms-meds/internal/testing"
+)
+
+// fakeAccountService is a BMC with a root account whose password can be
+// changed through its Redfish AccountService.
+type fakeAccountService struct {
+	lock        sync.Mutex
+	password    string
+	patchStatus int  // Status returned for PATCHes, if set
+	ignorePatch bool // Accept PATCHes without changing the password
+	failPatch   bool // Change the password but fail the PATCH, as if it timed out
+	patchOnce   bool // Reject PATCHes after the first, so rollbacks fail
+	patched     bool
+}
+
+// failingStore is a Vault that fails to store keys starting with any of
+// prefixes.
+type failingStore struct {
+	*mtest.KvMock
+	prefixes []string
+}
+
+func (f *failingStore) Store(key string, value interface{}) error {
+	for _, prefix := range f.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return errors.New("vault is sealed")
+		}
+	}
+	return f.KvMock.Store(key, value)
+}
+
+func (f *fakeAccountService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if user, pass, ok := r.BasicAuth(); !ok || user != "root" || pass != f.password {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	responses := map[string]string{
+		"/redfish/v1/AccountService/Accounts":   `{"Members":[{"@odata.id":"/redfish/v1/AccountService/Accounts/1"},{"@odata.id":"/redfish/v1/AccountService/Accounts/2"}]}`,
+		"/redfish/v1/AccountService/Accounts/1": `{"@odata.id":"/redfish/v1/AccountService/Accounts/1","Id":"1","UserName":"admin"}`,
+		"/redfish/v1/AccountService/Accounts/2": `{"@odata.id":"/redfish/v1/AccountService/Accounts/2","Id":"2","UserName":"root"}`,
+	}
+	switch r.Method {
+	case http.MethodGet:
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(json.RawMessage(body))
+	case http.MethodPatch:
+		if r.URL.Path != "/redfish/v1/AccountService/Accounts/2" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if f.patchStatus != 0 {
+			w.WriteHeader(f.patchStatus)
+			return
+		}
+		if f.patchOnce && f.patched {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		f.patched = true
+		var patch map[string]string
+		if json.NewDecoder(r.Body).Decode(&patch) != nil || patch["Password"] == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !f.ignorePatch {
+			f.password = patch["Password"]
+		}
+		if f.failPatch {
+			w.WriteHeader(http.StatusGatewayTimeout)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func Test_generatePassword(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 10; i++ {
+		password, err := generatePassword(ROTATION_PASSWORD_LENGTH)
+		if err != nil || len(password) != ROTATION_PASSWORD_LENGTH {
+			t.Errorf("Test %v Failed: Expected a %v character password; Received %q (%v)",
+				i, ROTATION_PASSWORD_LENGTH, password, err)
+		}
+		if strings.Trim(password, rotationPasswordChars) != "" {
+			t.Errorf("Test %v Failed: Unexpected characters in %q", i, password)
+		}
+		if seen[password] {
+			t.Errorf("Test %v Failed: %q generated twice", i, password)
+		}
+		seen[password] = true
+	}
+}
+
+func Test_rotateBMCPassword(t *testing.T) {
+	client, _ = hms_certs.CreateHTTPClientPair("", clientTimeout)
+	setupRFHTTPStuff()
+
+	savedKey := model.CredentialsKeyPrefix + "/" + model.CredentialsRotationKey + "/x1000c0s0b0"
+
+	tests := []struct {
+		description      string
+		newPassword      string
+		patchStatus      int
+		ignorePatch      bool
+		failPatch        bool
+		patchOnce        bool
+		failStores       []string // Prefixes of the Vault keys that can't be stored
+		expectSuccess    bool
+		expectRolledBack bool
+		expectBMC        string
+		expectVault      string
+		expectSaved      string // Password kept under the rotation key
+		expectUnsaved    string // Password returned because it couldn't be kept
+	}{{
+		"Rotated",
+		"n3wsecret", 0, false, false, false, nil,
+		true, false, "n3wsecret", "n3wsecret", "", "",
+	}, {
+		"Same password",
+		"secret", 0, false, false, false, nil,
+		false, false, "secret", "secret", "", "",
+	}, {
+		"PATCH rejected",
+		"n3wsecret", http.StatusBadRequest, false, false, false, nil,
+		false, false, "secret", "secret", "", "",
+	}, {
+		"PATCH failed but was applied",
+		"n3wsecret", 0, false, true, false, nil,
+		true, false, "n3wsecret", "n3wsecret", "", "",
+	}, {
+		"New password doesn't work",
+		"n3wsecret", 0, true, false, false, nil,
+		false, true, "secret", "secret", "", "",
+	}, {
+		"Store fails, rolled back",
+		"n3wsecret", 0, false, false, false, []string{"hms-creds"},
+		false, true, "secret", "secret", "", "",
+	}, {
+		"Store and rollback fail",
+		"n3wsecret", 0, false, false, true, []string{"hms-creds"},
+		false, false, "n3wsecret", "secret", "n3wsecret", "",
+	}, {
+		"Store, save and rollback fail",
+		"n3wsecret", 0, false, false, true, []string{"hms-creds", savedKey},
+		false, false, "n3wsecret", "secret", "", "n3wsecret",
+	}}
+
+	for i, test := range tests {
+		ss := &failingStore{KvMock: mtest.NewKvMock()}
+		hcs = compcreds.NewCompCredStore("hms-creds", ss)
+		credStorage = model.NewMedsCredStore(model.CredentialsKeyPrefix, ss)
+		hcs.StoreCompCred(compcreds.CompCredentials{Xname: "x1000c0s0b0", Username: "root", Password: "secret"})
+		ss.prefixes = test.failStores
+
+		bmc := &fakeAccountService{password: "secret", patchStatus: test.patchStatus,
+			ignorePatch: test.ignorePatch, failPatch: test.failPatch, patchOnce: test.patchOnce}
+		testServer := httptest.NewTLSServer(bmc)
+		address := strings.Split(testServer.URL, "//")[1]
+
+		result := rotateBMCPassword(context.Background(), "x1000c0s0b0", address, test.newPassword)
+		testServer.Close()
+
+		if result.Success != test.expectSuccess || result.RolledBack != test.expectRolledBack {
+			t.Errorf("Test %v (%s) Failed: Expected success %v, rolled back %v; Received %+v",
+				i, test.description, test.expectSuccess, test.expectRolledBack, result)
+		}
+		if !result.Success && result.Error == "" {
+			t.Errorf("Test %v (%s) Failed: Expected an error", i, test.description)
+		}
+		if bmc.password != test.expectBMC {
+			t.Errorf("Test %v (%s) Failed: Expected BMC password %q; Received %q",
+				i, test.description, test.expectBMC, bmc.password)
+		}
+		cred, _ := hcs.GetCompCred("x1000c0s0b0")
+		if cred.Password != test.expectVault {
+			t.Errorf("Test %v (%s) Failed: Expected Vault password %q; Received %q",
+				i, test.description, test.expectVault, cred.Password)
+		}
+		saved, _ := credStorage.FindRotationCredentials("x1000c0s0b0")
+		if saved.Password != test.expectSaved || (test.expectSaved != "" && result.SavedPasswordKey != savedKey) {
+			t.Errorf("Test %v (%s) Failed: Expected saved password %q; Received %q at %q",
+				i, test.description, test.expectSaved, saved.Password, result.SavedPasswordKey)
+		}
+		if result.UnsavedPassword != test.expectUnsaved {
+			t.Errorf("Test %v (%s) Failed: Expected unsaved password %q; Received %q",
+				i, test.description, test.expectUnsaved, result.UnsavedPassword)
+		}
+	}
+}
+
+func Test_rotateEndpoint(t *testing.T) {
+	var rotated []string
+	rotateCredentials = func(ctx context.Context, xname, address, newPassword string) RotationResult {
+		rotated = append(rotated, newPassword)
+		return RotationResult{Xname: xname, Success: true}
+	}
+	defer func() { rotateCredentials = rotateBMCPassword }()
+
+	tests := []struct {
+		description   string
+		hwtype        int
+		presence      HSMEndpointPresence
+		expectSuccess bool
+	}{
+		{"Present BMC", TYPE_NODE_CARD, PRESENCE_PRESENT, true},
+		{"Not present", TYPE_NODE_CARD, PRESENCE_NOT_PRESENT, false},
+		{"Environmental controller", TYPE_ENV_CONTROLLER, PRESENCE_PRESENT, false},
+	}
+
+	for i, test := range tests {
+		rotated = nil
+		ne := &NetEndpoint{
+			name:             "x1000c0s0b0",
+			hwtype:           test.hwtype,
+			HSMPresence:      test.presence,
+			lastPing:         time.Now(),
+			lastPingPresence: test.presence,
+		}
+		result := rotateEndpoint(context.Background(), ne)
+		if result.Success != test.expectSuccess {
+			t.Errorf("Test %v (%s) Failed: Expected success %v; Received %+v",
+				i, test.description, test.expectSuccess, result)
+		}
+		if !test.expectSuccess {
+			if len(rotated) != 0 {
+				t.Errorf("Test %v (%s) Failed: Expected no rotation", i, test.description)
+			}
+			continue
+		}
+		if len(rotated) != 1 {
+			t.Errorf("Test %v (%s) Failed: Expected one rotation; Received %v", i, test.description, len(rotated))
+		} else if len(rotated[0]) != ROTATION_PASSWORD_LENGTH {
+			t.Errorf("Test %v (%s) Failed: Expected a generated password; Received %q",
+				i, test.description, rotated[0])
+		}
+	}
+}
+
+func Test_doChassisRotatePost(t *testing.T) {
+	rotateCredentials = func(ctx context.Context, xname, address, newPassword string) RotationResult {
+		return RotationResult{Xname: xname, Success: true}
+	}
+	defer func() {
+		rotateCredentials = rotateBMCPassword
+		credentialRotation = false
+	}()
+
+	setupAPITestEndpoints()
+	activeEndpoints["x1000c3b0"].lastPing = time.Now()
+	activeEndpoints["x1000c3b0"].lastPingPresence = PRESENCE_PRESENT
+
+	tests := []struct {
+		description  string
+		enabled      bool
+		expectedCode int
+	}{
+		{"Rotation not enabled", false, http.StatusNotFound},
+		{"Only the present BMC is rotated", true, http.StatusMultiStatus},
+	}
+
+	for i, test := range tests {
+		credentialRotation = test.enabled
+		req := httptest.NewRequest(http.MethodPost, "/v1/chassis/x1000c3/rotate-credentials", nil)
+		w := httptest.NewRecorder()
+		newRouter().ServeHTTP(w, req)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("Test %v (%s) Failed: Expected status code %d; Received %d", i, test.description, test.expectedCode, w.Code)
+		}
+		if w.Code == http.StatusNotFound {
+			continue
+		}
+
+		var rsp RotationResultArray
+		if err := json.Unmarshal(w.Body.Bytes(), &rsp); err != nil {
+			t.Errorf("Test %v (%s) Failed: Unable to unmarshal response: %v", i, test.description, err)
+			continue
+		}
+		if len(rsp.Results) != 2 ||
+			rsp.Results[0].Xname != "x1000c3b0" || !rsp.Results[0].Success ||
+			rsp.Results[1].Xname != "x1000c3s5b1" || rsp.Results[1].Success {
+			t.Errorf("Test %v (%s) Failed: Unexpected results: %+v", i, test.description, rsp)
+		}
+	}
+}
@@ -0,0 +1,410 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Number of Redfish pings that may be in flight at once, across all
+// endpoints, and per cabinet (0 means no per-cabinet limit).
+var probeWorkers = 32
+var probeCabinetLimit = 8
+
+// probeItem is the scheduling state for one endpoint.
+type probeItem struct {
+	ne      *NetEndpoint
+	cabinet string
+	next    time.Time
+	index   int // Position in the queue, -1 when not queued
+	probes  int
+	running bool
+	removed bool
+	prevErr string
+}
+
+// probeQueue is a priority queue of endpoints ordered by next probe time.
+type probeQueue []*probeItem
+
+func (q probeQueue) Len() int           { return len(q) }
+func (q probeQueue) Less(i, j int) bool { return q[i].next.Before(q[j].next) }
+
+func (q probeQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *probeQueue) Push(x interface{}) {
+	item := x.(*probeItem)
+	item.index = len(*q)
+	*q = append(*q, item)
+}
+
+func (q *probeQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	*q = old[:n-1]
+	return item
+}
+
+// ProbeScheduler Redfish-pings every endpoint it is watching with a fixed
+// pool of workers.  Each endpoint is pinged every checkupFixedWait seconds,
+// plus or minus up to checkupVariableWaitMax seconds of jitter, and no more
+// than cabinetLimit endpoints in one cabinet are pinged at the same time.
+type ProbeScheduler struct {
+	lock          sync.Mutex
+	queue         probeQueue
+	items         map[string]*probeItem
+	waiting       map[string][]*probeItem // Due, but blocked by the cabinet limit
+	cabinetActive map[string]int
+	wake          chan struct{}
+
+	workers      int
+	cabinetLimit int
+	loopLimit    int // If non-zero, stop pinging an endpoint after this many pings
+
+	netQuery     func(context.Context, *NetEndpoint) (HSMEndpointPresence, *string, *error)
+	onPresent    func(context.Context, *NetEndpoint, string) *error
+	onNotPresent func(context.Context, NetEndpoint) *error
+}
+
+var probeScheduler *ProbeScheduler
+
+func NewProbeScheduler(
+	workers, cabinetLimit int,
+	netQuery func(context.Context, *NetEndpoint) (HSMEndpointPresence, *string, *error),
+	onPresent func(context.Context, *NetEndpoint, string) *error,
+	onNotPresent func(context.Context, NetEndpoint) *error) *ProbeScheduler {
+
+	if workers < 1 {
+		workers = 1
+	}
+	return &ProbeScheduler{
+		items:         make(map[string]*probeItem),
+		waiting:       make(map[string][]*probeItem),
+		cabinetActive: make(map[string]int),
+		wake:          make(chan struct{}, 1),
+		workers:       workers,
+		cabinetLimit:  cabinetLimit,
+		netQuery:      netQuery,
+		onPresent:     onPresent,
+		onNotPresent:  onNotPresent,
+	}
+}
+
+// Add starts watching an endpoint.  The first ping happens after a random
+// wait of up to startupVariableWaitMax seconds.
+func (s *ProbeScheduler) Add(ne *NetEndpoint, cabinet string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.items[ne.name]; ok {
+		return
+	}
+
+	log.Printf("INFO: Starting to watch %s.  It is currently %s in HSM", ne.name,
+		HSMEndpointPresenceToString[ne.HSMPresence])
+
+	item := &probeItem{
+		ne:      ne,
+		cabinet: cabinet,
+		next:    time.Now().Add(time.Duration(rand.Float64() * float64(startupVariableWaitMax) * float64(time.Second))),
+	}
+	s.items[ne.name] = item
+	heap.Push(&s.queue, item)
+	probeEndpointsGauge.Inc()
+	s.signal()
+}
+
+// Remove stops watching an endpoint.  A ping already in flight is allowed
+// to finish but the endpoint is not rescheduled.
+func (s *ProbeScheduler) Remove(xname string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	item, ok := s.items[xname]
+	if !ok {
+		return
+	}
+	log.Printf("INFO: No longer watching %s", xname)
+	s.forget(item)
+	s.signal()
+}
+
+// forget drops an endpoint from the scheduler.  The caller must hold s.lock.
+func (s *ProbeScheduler) forget(item *probeItem) {
+	item.removed = true
+	delete(s.items, item.ne.name)
+	probeEndpointsGauge.Dec()
+
+	if item.index >= 0 {
+		heap.Remove(&s.queue, item.index)
+	}
+	waiting := s.waiting[item.cabinet]
+	for i, w := range waiting {
+		if w == item {
+			s.waiting[item.cabinet] = append(waiting[:i], waiting[i+1:]...)
+			break
+		}
+	}
+}
+
+func (s *ProbeScheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run dispatches pings until ctx is cancelled or, with a loop limit set,
+// until every endpoint has been pinged loopLimit times.  It waits for any
+// pings in flight before returning.  Pings and the resulting HSM updates
+// use workCtx, so they can be allowed to finish after ctx is cancelled.
+func (s *ProbeScheduler) Run(ctx, workCtx context.Context) {
+	jobs := make(chan *probeItem)
+	var workers sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for item := range jobs {
+				s.probe(workCtx, item)
+			}
+		}()
+	}
+	defer func() {
+		close(jobs)
+		workers.Wait()
+	}()
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		s.lock.Lock()
+		if s.loopLimit > 0 && len(s.items) == 0 {
+			s.lock.Unlock()
+			return
+		}
+		item, wait := s.nextDue(time.Now())
+		s.lock.Unlock()
+
+		if item == nil {
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(wait)
+			select {
+			case <-timer.C:
+			case <-s.wake:
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+
+		select {
+		case jobs <- item:
+		case <-ctx.Done():
+			s.finish(item, 0)
+			return
+		}
+	}
+}
+
+// nextDue returns the next endpoint to ping, marking it as running, or how
+// long to wait before checking again.  The caller must hold s.lock.
+func (s *ProbeScheduler) nextDue(now time.Time) (*probeItem, time.Duration) {
+	for s.queue.Len() > 0 {
+		item := s.queue[0]
+		if item.next.After(now) {
+			return nil, item.next.Sub(now)
+		}
+		heap.Pop(&s.queue)
+
+		if s.cabinetLimit > 0 && s.cabinetActive[item.cabinet] >= s.cabinetLimit {
+			s.waiting[item.cabinet] = append(s.waiting[item.cabinet], item)
+			continue
+		}
+		s.start(item)
+		return item, 0
+	}
+
+	// Nothing queued; everything is either running or waiting on its
+	// cabinet, so sleep until a ping completes.
+	return nil, time.Hour
+}
+
+// start marks an endpoint as being pinged.  The caller must hold s.lock.
+func (s *ProbeScheduler) start(item *probeItem) {
+	item.running = true
+	item.probes++
+	s.cabinetActive[item.cabinet]++
+	probesInFlightGauge.Inc()
+}
+
+// finish reschedules an endpoint after a ping, after retryWait if that is
+// set, and lets the next endpoint waiting on the same cabinet go.
+func (s *ProbeScheduler) finish(item *probeItem, retryWait time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	item.running = false
+	s.cabinetActive[item.cabinet]--
+	probesInFlightGauge.Dec()
+
+	if waiting := s.waiting[item.cabinet]; len(waiting) > 0 {
+		next := waiting[0]
+		s.waiting[item.cabinet] = waiting[1:]
+		heap.Push(&s.queue, next)
+	}
+
+	if !item.removed {
+		if s.loopLimit > 0 && item.probes >= s.loopLimit {
+			log.Printf("INFO: No longer watching %s due to hitting loop count limit", item.ne.name)
+			s.forget(item)
+		} else if retryWait > 0 {
+			item.next = time.Now().Add(retryWait)
+			heap.Push(&s.queue, item)
+		} else {
+			// Jitter each wait so any bunching of pings eventually shifts apart.
+			item.next = time.Now().Add(jitterDuration(float64(checkupFixedWait),
+				float64(checkupVariableWaitMax)))
+			heap.Push(&s.queue, item)
+		}
+	}
+	s.signal()
+}
+
+func (s *ProbeScheduler) probe(ctx context.Context, item *probeItem) {
+	retryWait := probeEndpoint(ctx, item.ne, &item.prevErr, s.netQuery, s.onPresent, s.onNotPresent)
+	s.finish(item, retryWait)
+}
+
+// jitterDuration returns base seconds, plus or minus up to jitter seconds.
+func jitterDuration(base, jitter float64) time.Duration {
+	secs := base + (rand.Float64()-0.5)*2*jitter
+	if secs < 0 {
+		secs = 0
+	}
+	return time.Duration(secs * float64(time.Second))
+}
+
+// probeEndpoint Redfish-pings one endpoint and notifies HSM of any change
+// in its presence.  prevErr carries the error from the previous ping so
+// that a single failed ping does not cause a state change.  If the
+// endpoint couldn't be initialized, it returns how soon to ping it again
+// to retry, or 0 to wait as usual.
+func probeEndpoint(
+	ctx context.Context,
+	ne *NetEndpoint,
+	prevErr *string,
+	netQuery func(context.Context, *NetEndpoint) (HSMEndpointPresence, *string, *error),
+	onPresent func(context.Context, *NetEndpoint, string) *error,
+	onNotPresent func(context.Context, NetEndpoint) *error) (retryWait time.Duration) {
+
+	ne.HSMPresLock.Lock()
+	defer ne.HSMPresLock.Unlock()
+	reconnected := !ne.lastPing.IsZero() && ne.lastPingPresence == PRESENCE_NOT_PRESENT
+	netPresence, addr, err := netQuery(ctx, ne)
+	ne.lastPing = time.Now()
+	ne.lastPingPresence = netPresence
+	if err != nil {
+		ne.lastPingErr = fmt.Sprintf("%v", *err)
+	} else {
+		ne.lastPingErr = ""
+	}
+	if (err != nil) && (*prevErr == "") {
+		netPresence = ne.HSMPresence // ensure no state change on FIRST failure (but do one on second)
+	}
+	if err != nil {
+		*prevErr = fmt.Sprintf("%v", *err)
+	} else {
+		*prevErr = ""
+	}
+	if ne.lastPingPresence == PRESENCE_PRESENT || ne.HSMPresence == PRESENCE_NOT_PRESENT {
+		ne.missingSince = time.Time{}
+	} else if ne.missingSince.IsZero() {
+		ne.missingSince = ne.lastPing
+	}
+
+	// Dont want to move items to present if there was an error reaching them.
+	if netPresence == PRESENCE_PRESENT && ne.HSMPresence == PRESENCE_NOT_PRESENT && err == nil {
+		err := (onPresent(ctx, ne, *addr))
+		if err != nil {
+			log.Printf("WARNING: Failed to notify HSM that %s is now present: %v", ne.name, *err)
+			retryWait = initRetryDelay(ne.initStatus)
+		} else {
+			log.Printf("INFO: Marked %s ([%s]) present in HSM.", ne.name, *addr)
+			ne.setHSMPresence(PRESENCE_PRESENT)
+			ne.fingerprint = bmcFingerprint(ne)
+		}
+	} else if netPresence == PRESENCE_PRESENT && err == nil {
+		// Already present; make sure it is still the BMC MEDS set up.
+		reason := reinitReason(ctx, ne, *addr, reconnected)
+		if reason != "" {
+			reinitializations.WithLabelValues(reason).Inc()
+			log.Printf("INFO: Reinitializing %s ([%s]): %s", ne.name, *addr, reason)
+			err := onPresent(ctx, ne, *addr)
+			if err != nil {
+				// Treat it as new so the next ping tries again.
+				log.Printf("WARNING: Failed to reinitialize %s: %v", ne.name, *err)
+				ne.setHSMPresence(PRESENCE_NOT_PRESENT)
+				retryWait = initRetryDelay(ne.initStatus)
+			} else {
+				ne.fingerprint = bmcFingerprint(ne)
+			}
+		}
+	} else if netPresence == PRESENCE_NOT_PRESENT && ne.HSMPresence == PRESENCE_PRESENT &&
+		ne.lastPing.Sub(ne.missingSince) >= time.Duration(absentGrace)*time.Second {
+		err := onNotPresent(ctx, *ne)
+		if err != nil {
+			log.Printf("WARNING: Failed to notify HSM that %s is NOT present: %v", ne.name, *err)
+		} else {
+			log.Printf("INFO: Lost network contact with %s", ne.name)
+			if absentPolicy != ABSENT_POLICY_IGNORE {
+				// The next time it answers it is treated as new and gets
+				// credentials, NTP and syslog pushed again.
+				ne.setHSMPresence(PRESENCE_NOT_PRESENT)
+				ne.missingSince = time.Time{}
+				ne.locallyAbsent = absentPolicy == ABSENT_POLICY_ABSENT
+			}
+		}
+	}
+	return retryWait
+}
@@ -0,0 +1,395 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_ProbeScheduler_limits(t *testing.T) {
+	startupVariableWaitMax = 0
+	checkupFixedWait = 1
+	checkupVariableWaitMax = 0
+
+	tests := []struct {
+		description       string
+		workers           int
+		cabinetLimit      int
+		expectMaxTotal    int
+		expectMaxCabinet  int
+		expectQueryCounts int
+	}{{
+		"One worker",
+		1,
+		0,
+		1,
+		1,
+		8,
+	}, {
+		"Worker pool larger than the cabinet limit",
+		8,
+		2,
+		4,
+		2,
+		8,
+	}, {
+		"No cabinet limit",
+		8,
+		0,
+		8,
+		4,
+		8,
+	}}
+
+	for i, test := range tests {
+		var lock sync.Mutex
+		active := map[string]int{}
+		total, maxTotal, maxCabinet, count := 0, 0, 0, 0
+
+		netQuery := func(ctx context.Context, ne *NetEndpoint) (HSMEndpointPresence, *string, *error) {
+			cabinet := ne.name[:strings.Index(ne.name, "c")]
+			lock.Lock()
+			count++
+			total++
+			active[cabinet]++
+			if total > maxTotal {
+				maxTotal = total
+			}
+			if active[cabinet] > maxCabinet {
+				maxCabinet = active[cabinet]
+			}
+			lock.Unlock()
+
+			time.Sleep(100 * time.Millisecond)
+
+			lock.Lock()
+			total--
+			active[cabinet]--
+			lock.Unlock()
+			addr := ne.name
+			return PRESENCE_PRESENT, &addr, nil
+		}
+
+		s := NewProbeScheduler(test.workers, test.cabinetLimit, netQuery,
+			mock_notifyHSMPresent, mock_notifyHSMNotPresent)
+		s.loopLimit = 1
+		for _, cabinet := range []string{"x1000", "x1001"} {
+			for slot := 0; slot < 4; slot++ {
+				s.Add(&NetEndpoint{
+					name:        cabinet + "c0s" + string(rune('0'+slot)) + "b0",
+					HSMPresence: PRESENCE_PRESENT,
+				}, cabinet)
+			}
+		}
+		s.Run(context.Background(), context.Background())
+
+		if count != test.expectQueryCounts {
+			t.Errorf("Test %v (%s) Failed: Expected %d pings; Received %d", i, test.description, test.expectQueryCounts, count)
+		}
+		if maxTotal != test.expectMaxTotal {
+			t.Errorf("Test %v (%s) Failed: Expected at most %d pings in flight; Received %d", i, test.description, test.expectMaxTotal, maxTotal)
+		}
+		if maxCabinet != test.expectMaxCabinet {
+			t.Errorf("Test %v (%s) Failed: Expected at most %d pings in flight per cabinet; Received %d", i, test.description, test.expectMaxCabinet, maxCabinet)
+		}
+	}
+}
+
+func Test_ProbeScheduler_Remove(t *testing.T) {
+	startupVariableWaitMax = 0
+	checkupFixedWait = 1
+	checkupVariableWaitMax = 0
+
+	var lock sync.Mutex
+	counts := map[string]int{}
+	netQuery := func(ctx context.Context, ne *NetEndpoint) (HSMEndpointPresence, *string, *error) {
+		lock.Lock()
+		counts[ne.name]++
+		lock.Unlock()
+		addr := ne.name
+		return PRESENCE_PRESENT, &addr, nil
+	}
+
+	s := NewProbeScheduler(2, 0, netQuery, mock_notifyHSMPresent, mock_notifyHSMNotPresent)
+	s.loopLimit = 2
+	s.Add(&NetEndpoint{name: "x1000c0s0b0", HSMPresence: PRESENCE_PRESENT}, "x1000")
+	s.Add(&NetEndpoint{name: "x1000c0s1b0", HSMPresence: PRESENCE_PRESENT}, "x1000")
+	s.Remove("x1000c0s1b0")
+	s.Run(context.Background(), context.Background())
+
+	if counts["x1000c0s0b0"] != 2 || counts["x1000c0s1b0"] != 0 {
+		t.Errorf("Unexpected ping counts: %v", counts)
+	}
+	if len(s.items) != 0 || s.queue.Len() != 0 {
+		t.Errorf("Scheduler not empty after hitting loop limit: %d items, %d queued", len(s.items), s.queue.Len())
+	}
+}
+
+func Test_probeEndpoint_absentPolicy(t *testing.T) {
+	defer func() {
+		absentPolicy = ABSENT_POLICY_IGNORE
+		absentGrace = 0
+	}()
+
+	pingErr := errors.New("Dummy: Can't find endpoint")
+
+	tests := []struct {
+		description         string
+		policy              string
+		grace               int
+		missingFor          time.Duration // How long the endpoint has already been unreachable, 0 if it hasn't
+		expectNotPresCalls  int
+		expectPresence      HSMEndpointPresence
+		expectLocallyAbsent bool
+	}{{
+		"First failure is ignored",
+		ABSENT_POLICY_ABSENT,
+		0,
+		0,
+		0,
+		PRESENCE_PRESENT,
+		false,
+	}, {
+		"Ignore policy leaves the endpoint present",
+		ABSENT_POLICY_IGNORE,
+		0,
+		30 * time.Second,
+		1,
+		PRESENCE_PRESENT,
+		false,
+	}, {
+		"Absent policy, within the grace period",
+		ABSENT_POLICY_ABSENT,
+		120,
+		30 * time.Second,
+		0,
+		PRESENCE_PRESENT,
+		false,
+	}, {
+		"Absent policy, grace period expired",
+		ABSENT_POLICY_ABSENT,
+		120,
+		150 * time.Second,
+		1,
+		PRESENCE_NOT_PRESENT,
+		true,
+	}, {
+		"Disable policy, grace period expired",
+		ABSENT_POLICY_DISABLE,
+		120,
+		150 * time.Second,
+		1,
+		PRESENCE_NOT_PRESENT,
+		false,
+	}}
+
+	for i, test := range tests {
+		absentPolicy = test.policy
+		absentGrace = test.grace
+		configure_queryNet(PRESENCE_NOT_PRESENT, nil, &pingErr)
+		configure_notifyHSMPresent(nil)
+		configure_notifyHSMNotPresent(nil)
+
+		node := NetEndpoint{name: "x1000c0s0b0", HSMPresence: PRESENCE_PRESENT}
+		prevErr := ""
+		if test.missingFor != 0 {
+			node.missingSince = time.Now().Add(-test.missingFor)
+			prevErr = pingErr.Error()
+		}
+
+		probeEndpoint(context.Background(), &node, &prevErr, mock_queryNet, mock_notifyHSMPresent, mock_notifyHSMNotPresent)
+
+		if len(notifyHSMNotPresentCalls) != test.expectNotPresCalls {
+			t.Errorf("Test %v (%s) Failed: Expected %d notifyHSMNotPresent calls; Received %d", i, test.description, test.expectNotPresCalls, len(notifyHSMNotPresentCalls))
+		}
+		if node.HSMPresence != test.expectPresence || node.locallyAbsent != test.expectLocallyAbsent {
+			t.Errorf("Test %v (%s) Failed: Expected presence %s (locally absent %v); Received %s (%v)", i, test.description,
+				HSMEndpointPresenceToString[test.expectPresence], test.expectLocallyAbsent,
+				HSMEndpointPresenceToString[node.HSMPresence], node.locallyAbsent)
+		}
+		if test.expectPresence == PRESENCE_PRESENT && node.missingSince.IsZero() {
+			t.Errorf("Test %v (%s) Failed: Missing time not recorded", i, test.description)
+		}
+	}
+
+	// Once it is back, a locally absent endpoint is re-initialized.
+	addr := "x1000c0s0b0"
+	configure_queryNet(PRESENCE_PRESENT, &addr, nil)
+	configure_notifyHSMPresent(nil)
+	node := NetEndpoint{name: addr, HSMPresence: PRESENCE_NOT_PRESENT, locallyAbsent: true}
+	prevErr := pingErr.Error()
+	probeEndpoint(context.Background(), &node, &prevErr, mock_queryNet, mock_notifyHSMPresent, mock_notifyHSMNotPresent)
+	if len(notifyHSMPresentCalls) != 1 || node.HSMPresence != PRESENCE_PRESENT || node.locallyAbsent {
+		t.Errorf("Reappearing endpoint not re-initialized: %d notifyHSMPresent calls, presence %s, locally absent %v",
+			len(notifyHSMPresentCalls), HSMEndpointPresenceToString[node.HSMPresence], node.locallyAbsent)
+	}
+}
+
+func Test_probeEndpoint_reinitialize(t *testing.T) {
+	defer func() { checkFactorySettings = bmcHasFactorySettings }()
+
+	tests := []struct {
+		description     string
+		fingerprint     string // What MEDS saw before
+		uuid            string // What the BMC reports now
+		lastPing        HSMEndpointPresence
+		factorySettings bool
+		presErr         error
+		expectPresCalls int
+		expectPresence  HSMEndpointPresence
+		expectFinger    string
+	}{{
+		"Same BMC",
+		"uuid-1", "uuid-1", PRESENCE_PRESENT, true, nil,
+		0, PRESENCE_PRESENT, "uuid-1",
+	}, {
+		"First fingerprint is just recorded",
+		"", "uuid-1", PRESENCE_PRESENT, false, nil,
+		0, PRESENCE_PRESENT, "uuid-1",
+	}, {
+		"Blade swapped",
+		"uuid-1", "uuid-2", PRESENCE_NOT_PRESENT, false, nil,
+		1, PRESENCE_PRESENT, "uuid-2",
+	}, {
+		"Came back with its settings",
+		"uuid-1", "uuid-1", PRESENCE_NOT_PRESENT, false, nil,
+		0, PRESENCE_PRESENT, "uuid-1",
+	}, {
+		"Came back with factory settings",
+		"uuid-1", "uuid-1", PRESENCE_NOT_PRESENT, true, nil,
+		1, PRESENCE_PRESENT, "uuid-1",
+	}, {
+		"Reinitialization fails",
+		"uuid-1", "uuid-2", PRESENCE_PRESENT, false, errors.New("HSM is down"),
+		1, PRESENCE_NOT_PRESENT, "uuid-1",
+	}}
+
+	for i, test := range tests {
+		addr := "x1000c0s0b0"
+		configure_queryNet(PRESENCE_PRESENT, &addr, nil)
+		if test.presErr != nil {
+			configure_notifyHSMPresent(&test.presErr)
+		} else {
+			configure_notifyHSMPresent(nil)
+		}
+		configure_notifyHSMNotPresent(nil)
+		checkFactorySettings = func(ctx context.Context, xname, address string) bool {
+			return test.factorySettings
+		}
+
+		node := NetEndpoint{
+			name:             addr,
+			hwtype:           TYPE_NODE_CARD,
+			HSMPresence:      PRESENCE_PRESENT,
+			lastPing:         time.Now().Add(-30 * time.Second),
+			lastPingPresence: test.lastPing,
+			fingerprint:      test.fingerprint,
+			serviceRoot:      ServiceRootInfo{Result: PROBE_PRESENT, UUID: test.uuid},
+		}
+		prevErr := ""
+		probeEndpoint(context.Background(), &node, &prevErr, mock_queryNet, mock_notifyHSMPresent, mock_notifyHSMNotPresent)
+
+		if len(notifyHSMPresentCalls) != test.expectPresCalls {
+			t.Errorf("Test %v (%s) Failed: Expected %d notifyHSMPresent calls; Received %d", i, test.description, test.expectPresCalls, len(notifyHSMPresentCalls))
+		}
+		if node.HSMPresence != test.expectPresence || node.fingerprint != test.expectFinger {
+			t.Errorf("Test %v (%s) Failed: Expected presence %s and fingerprint %s; Received %s and %s", i, test.description,
+				HSMEndpointPresenceToString[test.expectPresence], test.expectFinger,
+				HSMEndpointPresenceToString[node.HSMPresence], node.fingerprint)
+		}
+	}
+}
+
+func Test_probeEndpoint_initRetry(t *testing.T) {
+	addr := "x1000c0s0b0"
+	configure_queryNet(PRESENCE_PRESENT, &addr, nil)
+	configure_notifyHSMNotPresent(nil)
+
+	// HSM is down, so every initialization fails at the HSM step.
+	onPresent := func(ctx context.Context, ne *NetEndpoint, address string) *error {
+		p := newInitPipeline(ne)
+		p.run(STEP_HSM, true, func() error { return errors.New("HSM is down") })
+		return p.finish()
+	}
+
+	node := NetEndpoint{name: addr, HSMPresence: PRESENCE_NOT_PRESENT}
+	prevErr := ""
+	for i := 0; i <= initRetries+1; i++ {
+		expected := time.Duration(0)
+		if i < initRetries {
+			expected = initRetryWait << i
+		}
+		retryWait := probeEndpoint(context.Background(), &node, &prevErr, mock_queryNet, onPresent, mock_notifyHSMNotPresent)
+		if retryWait != expected {
+			t.Errorf("Ping %v Failed: Expected to ping again after %v; Received %v", i, expected, retryWait)
+		}
+		if node.HSMPresence != PRESENCE_NOT_PRESENT {
+			t.Errorf("Ping %v Failed: Endpoint marked present", i)
+		}
+	}
+}
+
+func Test_ProbeScheduler_drain(t *testing.T) {
+	startupVariableWaitMax = 0
+	checkupFixedWait = 1
+	checkupVariableWaitMax = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	var workErr error
+	netQuery := func(workCtx context.Context, ne *NetEndpoint) (HSMEndpointPresence, *string, *error) {
+		// Shut down while this ping is in flight.
+		close(started)
+		cancel()
+		time.Sleep(100 * time.Millisecond)
+		workErr = workCtx.Err()
+		addr := ne.name
+		return PRESENCE_PRESENT, &addr, nil
+	}
+
+	node := &NetEndpoint{name: "x1000c0s0b0", HSMPresence: PRESENCE_PRESENT}
+	s := NewProbeScheduler(1, 0, netQuery, mock_notifyHSMPresent, mock_notifyHSMNotPresent)
+	s.Add(node, "x1000")
+	s.Run(ctx, context.Background())
+
+	select {
+	case <-started:
+	default:
+		t.Fatalf("Endpoint never pinged")
+	}
+	if workErr != nil {
+		t.Errorf("In-flight ping was cancelled: %v", workErr)
+	}
+	node.HSMPresLock.Lock()
+	defer node.HSMPresLock.Unlock()
+	if node.lastPing.IsZero() {
+		t.Errorf("Run returned before the in-flight ping finished")
+	}
+}
@@ -0,0 +1,379 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+	"github.com/Cray-HPE/hms-smd/v2/pkg/sm"
+	"github.com/Cray-HPE/hms-xname/xnametypes"
+)
+
+// URL at which HSM should deliver state change notifications (SCNs) to
+// MEDS.  If empty, MEDS does not subscribe and relies on polling HSM.
+var scnURL string
+
+// How often to poll HSM in full when SCNs are being received.  This is
+// also how often MEDS makes sure it is still subscribed.
+var hsmSyncFallbackInterval = 1800 // In seconds
+
+// The name MEDS subscribes to SCNs under.  It is the same for every MEDS
+// instance so that a restarted MEDS finds its subscription rather than
+// adding another.
+const scnSubscriber = "meds"
+
+// HSM component states we ask to be notified about: hardware that has been
+// removed or (re)discovered.  Power state changes don't matter to MEDS.
+// Changes to whether a component is enabled are asked for with Enabled.
+// HSM sends no SCN when a RedfishEndpoint is deleted or disabled, so those
+// are only picked up by the full sync.
+var scnStates = []string{"Empty", "Populated"}
+
+// The ID HSM gave MEDS' subscription, or 0 if it isn't known.
+var scnSubscriptionID int64
+var scnSubscriptionLock sync.Mutex
+
+// Whether an SCN has arrived since MEDS last subscribed, which confirms HSM
+// can deliver them.  Until then the full HSM sync keeps its normal
+// interval.
+var scnConfirmed bool
+var scnConfirmedLock sync.Mutex
+
+// subscribeSCN registers an SCN subscription with HSM.  HSM keeps
+// subscriptions across restarts, so an existing one is not an error.
+func subscribeSCN(ctx context.Context, url string) error {
+	enabled := true
+	payload := sm.SCNPostSubscription{
+		Subscriber: scnSubscriber,
+		Enabled:    &enabled,
+		States:     scnStates,
+		Url:        url,
+	}
+	rawPayload, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("DEBUG: POST to %s/Subscriptions/SCN with %s", hsm, string(rawPayload))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hsm+"/Subscriptions/SCN", bytes.NewReader(rawPayload))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	base.SetHTTPUserAgent(req, serviceName)
+	resp, err := client.Do(req)
+	defer base.DrainAndCloseResponseBody(resp)
+	recordRequest("hsm", "post_scn_subscription", resp, err)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated {
+		log.Printf("INFO: Subscribed to SCNs from HSM at %s", url)
+	} else if resp.StatusCode == http.StatusConflict {
+		log.Printf("INFO: Already subscribed to SCNs from HSM at %s", url)
+	}
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated ||
+		resp.StatusCode == http.StatusConflict {
+		// The ID is needed to unsubscribe when MEDS stops.
+		err = findSCNSubscription(ctx, url)
+		if err != nil {
+			log.Printf("WARNING: Unable to find the SCN subscription in HSM: %v", err)
+		}
+		return nil
+	}
+
+	var strbody []byte
+	if resp.Body != nil {
+		strbody, _ = ioutil.ReadAll(resp.Body)
+	}
+	return fmt.Errorf("Unable to subscribe to SCNs from HSM: %d\n%s", resp.StatusCode, string(strbody))
+}
+
+// getSCNSubscriptions fetches every SCN subscription from HSM.
+func getSCNSubscriptions(ctx context.Context) ([]sm.SCNSubscription, error) {
+	log.Printf("DEBUG: GET from %s/Subscriptions/SCN", hsm)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, hsm+"/Subscriptions/SCN", nil)
+	if err != nil {
+		return nil, err
+	}
+	base.SetHTTPUserAgent(req, serviceName)
+	resp, err := client.Do(req)
+	defer base.DrainAndCloseResponseBody(resp)
+	recordRequest("hsm", "get_scn_subscriptions", resp, err)
+	if err != nil {
+		return nil, err
+	}
+
+	var bodyBytes []byte
+	if resp.Body != nil {
+		bodyBytes, _ = ioutil.ReadAll(resp.Body)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unable to retrieve SCN subscriptions from HSM: %d\n%s",
+			resp.StatusCode, string(bodyBytes))
+	}
+	var subs sm.SCNSubscriptionArray
+	err = json.Unmarshal(bodyBytes, &subs)
+	return subs.SubscriptionList, err
+}
+
+// findSCNSubscription records the ID of MEDS' subscription to SCNs at url,
+// and deletes the subscriptions earlier versions of MEDS made there under
+// a name for each instance, which nothing else would remove.
+func findSCNSubscription(ctx context.Context, url string) error {
+	subs, err := getSCNSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		if sub.Url != url {
+			continue
+		}
+		if sub.Subscriber == scnSubscriber {
+			scnSubscriptionLock.Lock()
+			scnSubscriptionID = sub.ID
+			scnSubscriptionLock.Unlock()
+		} else if strings.HasPrefix(sub.Subscriber, scnSubscriber+"@") {
+			log.Printf("INFO: Removing stale SCN subscription %d for %s", sub.ID, sub.Subscriber)
+			err = deleteSCNSubscription(ctx, sub.ID)
+			if err != nil {
+				log.Printf("WARNING: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// deleteSCNSubscription deletes an SCN subscription from HSM.  One that is
+// already gone is not an error.
+func deleteSCNSubscription(ctx context.Context, id int64) error {
+	url := fmt.Sprintf("%s/Subscriptions/SCN/%d", hsm, id)
+	log.Printf("DEBUG: DELETE %s", url)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return err
+	}
+	base.SetHTTPUserAgent(req, serviceName)
+	resp, err := client.Do(req)
+	defer base.DrainAndCloseResponseBody(resp)
+	recordRequest("hsm", "delete_scn_subscription", resp, err)
+	if err != nil {
+		return err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+	var strbody []byte
+	if resp.Body != nil {
+		strbody, _ = ioutil.ReadAll(resp.Body)
+	}
+	return fmt.Errorf("Unable to delete SCN subscription %d from HSM: %d\n%s", id, resp.StatusCode, string(strbody))
+}
+
+// unsubscribeSCN removes MEDS' SCN subscription from HSM when MEDS stops, so
+// that HSM doesn't keep sending SCNs nobody receives.  A MEDS that started
+// before this one stopped subscribes again within the fallback interval.
+func unsubscribeSCN(ctx context.Context) {
+	scnSubscriptionLock.Lock()
+	id := scnSubscriptionID
+	scnSubscriptionID = 0
+	scnSubscriptionLock.Unlock()
+	if id == 0 {
+		return
+	}
+
+	err := deleteSCNSubscription(ctx, id)
+	if err != nil {
+		log.Printf("WARNING: %v", err)
+		return
+	}
+	log.Printf("INFO: Unsubscribed from SCNs from HSM")
+}
+
+// watchSCNSubscription subscribes to SCNs, retrying until it succeeds,
+// and subscribes again every fallback interval in case HSM has lost the
+// subscription.  If subscribing fails the full HSM sync goes back to its
+// normal interval until an SCN arrives again.
+func watchSCNSubscription(ctx context.Context, url string) {
+	retryWait := 5 * time.Second
+	for {
+		wait := time.Duration(hsmSyncFallbackInterval) * time.Second
+		err := subscribeSCN(ctx, url)
+		if err == nil {
+			retryWait = 5 * time.Second
+		} else {
+			log.Printf("WARNING: %v; trying again in %d seconds", err, retryWait/time.Second)
+			if unconfirmSCNDelivery() {
+				hsmReconciler.SetInterval(time.Duration(hsmSyncInterval) * time.Second)
+			}
+			wait = retryWait
+			if retryWait < 5*time.Minute {
+				retryWait *= 2
+			}
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// confirmSCNDelivery records that an SCN has arrived.  The first one after
+// subscribing lowers the frequency of full HSM syncs.
+func confirmSCNDelivery() {
+	scnConfirmedLock.Lock()
+	confirmed := scnConfirmed
+	scnConfirmed = true
+	scnConfirmedLock.Unlock()
+
+	if !confirmed && hsmReconciler != nil {
+		log.Printf("INFO: Receiving SCNs from HSM")
+		hsmReconciler.SetInterval(time.Duration(hsmSyncFallbackInterval) * time.Second)
+	}
+}
+
+// unconfirmSCNDelivery forgets that SCNs have arrived, and says whether
+// they had.
+func unconfirmSCNDelivery() bool {
+	scnConfirmedLock.Lock()
+	defer scnConfirmedLock.Unlock()
+	confirmed := scnConfirmed
+	scnConfirmed = false
+	return confirmed && hsmReconciler != nil
+}
+
+// getHSMRedfishEndpoint fetches a single RedfishEndpoint from HSM.  found
+// is false if HSM doesn't have it.
+func getHSMRedfishEndpoint(ctx context.Context, xname string) (rfEP HSMNotification, found bool, err error) {
+	log.Printf("DEBUG: GET from %s/Inventory/RedfishEndpoints/%s", hsm, xname)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, hsm+"/Inventory/RedfishEndpoints/"+xname, nil)
+	if err != nil {
+		return rfEP, false, err
+	}
+	base.SetHTTPUserAgent(req, serviceName)
+	resp, err := client.Do(req)
+	defer base.DrainAndCloseResponseBody(resp)
+	recordRequest("hsm", "get_redfish_endpoint", resp, err)
+	if err != nil {
+		return rfEP, false, err
+	}
+
+	var bodyBytes []byte
+	if resp.Body != nil {
+		bodyBytes, _ = ioutil.ReadAll(resp.Body)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		err = json.Unmarshal(bodyBytes, &rfEP)
+		return rfEP, err == nil, err
+	case http.StatusNotFound:
+		return rfEP, false, nil
+	}
+	return rfEP, false, fmt.Errorf("Unable to retrieve %s from HSM: %d\n%s",
+		xname, resp.StatusCode, string(bodyBytes))
+}
+
+// handleSCN refreshes the HSMPresence of every tracked endpoint an SCN is
+// about from HSM.  Notifications about components below a BMC, such as
+// nodes, refresh the BMC.
+func handleSCN(ctx context.Context, scn sm.SCNPayload) {
+	endpoints := make(map[string]*NetEndpoint)
+	activeEndpointsLock.Lock()
+	for _, comp := range scn.Components {
+		xname := xnametypes.NormalizeHMSCompID(comp)
+		for xname != "" {
+			if ne, ok := activeEndpoints[xname]; ok {
+				endpoints[xname] = ne
+				break
+			}
+			xname = xnametypes.GetHMSCompParent(xname)
+		}
+	}
+	activeEndpointsLock.Unlock()
+
+	for xname, ne := range endpoints {
+		rfEP, found, err := getHSMRedfishEndpoint(ctx, xname)
+		if err != nil {
+			log.Printf("WARNING: Unable to refresh %s from HSM after SCN: %v", xname, err)
+			continue
+		}
+
+		ne.HSMPresLock.Lock()
+		applyHSMRedfishEndpoint(ne, rfEP, found)
+		ne.HSMPresLock.Unlock()
+
+		hsmRedfishEndpointsCacheLock.Lock()
+		if hsmRedfishEndpointsCache != nil {
+			if found {
+				hsmRedfishEndpointsCache[xname] = rfEP
+			} else {
+				delete(hsmRedfishEndpointsCache, xname)
+			}
+		}
+		hsmRedfishEndpointsCacheLock.Unlock()
+	}
+}
+
+// POST /v1/scn
+//
+// HSM only needs to know the notification arrived, so the endpoints are
+// refreshed in the background, after the request has completed.
+func doSCNPost(w http.ResponseWriter, r *http.Request) {
+	var scn sm.SCNPayload
+	body, err := ioutil.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(body, &scn)
+	}
+	if err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest,
+			"Unable to parse SCN: "+err.Error())
+		return
+	}
+
+	log.Printf("DEBUG: Received SCN for %v", scn.Components)
+	confirmSCNDelivery()
+	go handleSCN(context.WithoutCancel(r.Context()), scn)
+	w.WriteHeader(http.StatusOK)
+}
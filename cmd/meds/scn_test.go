@@ -0,0 +1,211 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/hms-certs/pkg/hms_certs"
+	"github.com/Cray-HPE/hms-smd/v2/pkg/sm"
+)
+
+func Test_subscribeSCN(t *testing.T) {
+	defer func() { scnSubscriptionID = 0 }()
+
+	tests := []struct {
+		description string
+		respCode    int
+		expectErr   bool
+		expectID    int64
+	}{{
+		"Created (201) from HSM",
+		201,
+		false,
+		7,
+	}, {
+		"Already subscribed (409) from HSM",
+		409,
+		false,
+		7,
+	}, {
+		"Error (500) from HSM",
+		500,
+		true,
+		0,
+	}}
+
+	var responseCode int
+	var sub sm.SCNPostSubscription
+	var deleted []string
+	serviceName = "MEDS_TEST"
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !userAgentHeaderPresent(r) {
+			t.Errorf("Request %s had no User-Agent header.", r.URL.String())
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.String() == "/Subscriptions/SCN":
+			body, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(body, &sub)
+			w.WriteHeader(responseCode)
+		case r.Method == http.MethodGet && r.URL.String() == "/Subscriptions/SCN":
+			// MEDS' subscription, one left by an old MEDS pod, and
+			// someone else's.
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(json.RawMessage(`{"SubscriptionList":[
+				{"ID":3,"Subscriber":"meds@cray-meds-6f7d8-abcde","Url":"http://meds/v1/scn"},
+				{"ID":7,"Subscriber":"meds","Url":"http://meds/v1/scn"},
+				{"ID":9,"Subscriber":"hbtd@cray-hbtd-1","Url":"http://hbtd/v1/scn"}]}`))
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.String())
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.String())
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+	hsm = testServer.URL
+	client, _ = hms_certs.CreateHTTPClientPair("", clientTimeout)
+
+	for i, test := range tests {
+		responseCode = test.respCode
+		sub = sm.SCNPostSubscription{}
+		deleted = nil
+		scnSubscriptionID = 0
+		err := subscribeSCN(context.Background(), "http://meds/v1/scn")
+		if (err != nil) != test.expectErr {
+			t.Errorf("Test %v (%s) Failed: Unexpected error result: %v", i, test.description, err)
+		}
+		if sub.Url != "http://meds/v1/scn" || sub.Subscriber != "meds" || len(sub.States) == 0 ||
+			sub.Enabled == nil || !*sub.Enabled {
+			t.Errorf("Test %v (%s) Failed: Unexpected subscription %+v", i, test.description, sub)
+		}
+		if scnSubscriptionID != test.expectID {
+			t.Errorf("Test %v (%s) Failed: Expected subscription ID %d; Received %d",
+				i, test.description, test.expectID, scnSubscriptionID)
+		}
+		if !test.expectErr && (len(deleted) != 1 || deleted[0] != "/Subscriptions/SCN/3") {
+			t.Errorf("Test %v (%s) Failed: Expected the stale subscription deleted; Received %v",
+				i, test.description, deleted)
+		}
+	}
+
+	// Stopping removes the subscription, once.
+	deleted = nil
+	scnSubscriptionID = 7
+	unsubscribeSCN(context.Background())
+	unsubscribeSCN(context.Background())
+	if len(deleted) != 1 || deleted[0] != "/Subscriptions/SCN/7" {
+		t.Errorf("Expected the subscription deleted once when stopping; Received %v", deleted)
+	}
+}
+
+func Test_handleSCN(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch strings.TrimPrefix(r.URL.String(), "/Inventory/RedfishEndpoints/") {
+		case "x1000c3b0":
+			// Deleted from HSM
+			w.WriteHeader(http.StatusNotFound)
+		case "x1000c3s5b1":
+			w.WriteHeader(http.StatusOK)
+			w.Write(json.RawMessage(`{"ID":"x1000c3s5b1","Enabled":true,"UUID":"8a8b6f2e-3c49-4d3b-9a0e-3c6a2d1d0001"}`))
+		default:
+			t.Errorf("Unexpected request %s", r.URL.String())
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer testServer.Close()
+	hsm = testServer.URL
+	client, _ = hms_certs.CreateHTTPClientPair("", clientTimeout)
+
+	setupAPITestEndpoints()
+	hsmRedfishEndpointsCache = map[string]HSMNotification{"x1000c3b0": {ID: "x1000c3b0"}}
+
+	handleSCN(context.Background(), sm.SCNPayload{
+		// A node under a tracked BMC, a tracked BMC, and something MEDS
+		// doesn't track at all.
+		Components: []string{"x1000c3s5b1n0", "x1000c3b0", "x3000c0s1b0n0"},
+		State:      "Empty",
+	})
+
+	if activeEndpoints["x1000c3b0"].HSMPresence != PRESENCE_NOT_PRESENT {
+		t.Errorf("Endpoint deleted from HSM is still present")
+	}
+	if activeEndpoints["x1000c3s5b1"].HSMPresence != PRESENCE_PRESENT {
+		t.Errorf("Endpoint enabled in HSM is not present")
+	}
+	if activeEndpoints["x1000c3s5b1"].fingerprint != "8a8b6f2e-3c49-4d3b-9a0e-3c6a2d1d0001" {
+		t.Errorf("Endpoint fingerprint not seeded from HSM; Received '%s'", activeEndpoints["x1000c3s5b1"].fingerprint)
+	}
+	if _, ok := hsmRedfishEndpointsCache["x1000c3b0"]; ok {
+		t.Errorf("Endpoint deleted from HSM is still in the cache")
+	}
+	if _, ok := hsmRedfishEndpointsCache["x1000c3s5b1"]; !ok {
+		t.Errorf("Endpoint enabled in HSM is not in the cache")
+	}
+}
+
+func Test_doSCNPost(t *testing.T) {
+	defer func() { hsmReconciler = nil }()
+	hsmReconciler = NewHSMReconciler(time.Duration(hsmSyncInterval)*time.Second, time.Second, time.Second,
+		func(context.Context) error { return nil })
+	unconfirmSCNDelivery()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/scn", strings.NewReader("not json"))
+	w := httptest.NewRecorder()
+	newRouter().ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a bad SCN, got %d", w.Code)
+	}
+	if hsmReconciler.getInterval() != time.Duration(hsmSyncInterval)*time.Second {
+		t.Errorf("A bad SCN lowered the HSM sync interval to %v", hsmReconciler.getInterval())
+	}
+
+	// The first SCN confirms delivery and lowers the full sync frequency.
+	req = httptest.NewRequest(http.MethodPost, "/v1/scn", strings.NewReader(`{"Components":[]}`))
+	w = httptest.NewRecorder()
+	newRouter().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200 for an SCN, got %d", w.Code)
+	}
+	if hsmReconciler.getInterval() != time.Duration(hsmSyncFallbackInterval)*time.Second {
+		t.Errorf("Expected the fallback HSM sync interval after an SCN, got %v", hsmReconciler.getInterval())
+	}
+
+	if !unconfirmSCNDelivery() {
+		t.Errorf("Expected SCN delivery to have been confirmed")
+	}
+	if unconfirmSCNDelivery() {
+		t.Errorf("Expected SCN delivery to have been forgotten")
+	}
+}
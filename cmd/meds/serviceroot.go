@@ -0,0 +1,131 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// How a Redfish ping of an endpoint's service root turned out.  Only
+// PROBE_PRESENT counts as the endpoint being present.
+const (
+	PROBE_PRESENT       = "present"       // A Redfish service root with Managers and Chassis
+	PROBE_BOOTING       = "booting"       // A Redfish service that isn't fully up yet
+	PROBE_WRONG_DEVICE  = "wrong_device"  // Something answered, but not a Redfish service root
+	PROBE_AUTH_REQUIRED = "auth_required" // The service root needs credentials, which BMCs don't ask for
+	PROBE_BAD_STATUS    = "bad_status"    // Any other HTTP status
+	PROBE_ERROR         = "error"         // No HTTP response at all
+)
+
+// ServiceRootInfo is what the last Redfish ping of an endpoint found.
+// The vendor, product, UUID and version are from the last ping that found
+// the endpoint present.
+type ServiceRootInfo struct {
+	Result         string `json:"Result"`
+	Vendor         string `json:"Vendor,omitempty"`
+	Product        string `json:"Product,omitempty"`
+	UUID           string `json:"UUID,omitempty"`
+	RedfishVersion string `json:"RedfishVersion,omitempty"`
+}
+
+// Comma separated list of the vendors whose service roots MEDS treats as
+// its BMCs, compared without regard to case.  Empty allows any vendor.
+var serviceRootVendorList = "Cray,HPE,Hewlett Packard Enterprise"
+var serviceRootVendors = parseVendorList(serviceRootVendorList)
+
+// parseVendorList splits a comma separated vendor list.
+func parseVendorList(list string) []string {
+	vendors := make([]string, 0)
+	for _, vendor := range strings.Split(list, ",") {
+		vendor = strings.TrimSpace(vendor)
+		if vendor != "" {
+			vendors = append(vendors, vendor)
+		}
+	}
+	return vendors
+}
+
+// vendorAllowed checks a service root's vendor against vendors.  Older
+// service roots don't report a vendor, so an empty one is allowed.
+func vendorAllowed(vendors []string, vendor string) bool {
+	if len(vendors) == 0 || vendor == "" {
+		return true
+	}
+	for _, allowed := range vendors {
+		if strings.EqualFold(allowed, vendor) {
+			return true
+		}
+	}
+	return false
+}
+
+// classifyServiceRoot decides what answered a GET of /redfish/v1/ from the
+// HTTP status and body.  An error describes every result but
+// PROBE_PRESENT.
+func classifyServiceRoot(status int, body []byte) (string, RedfishServiceRoot, error) {
+	var root RedfishServiceRoot
+
+	switch status {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return PROBE_AUTH_REQUIRED, root, fmt.Errorf("Service root requires authentication: (%d)", status)
+	case http.StatusServiceUnavailable:
+		return PROBE_BOOTING, root, fmt.Errorf("Redfish service unavailable: (%d): %v", status, string(body))
+	default:
+		return PROBE_BAD_STATUS, root, fmt.Errorf("Bad return status: (%d): %v", status, string(body))
+	}
+
+	err := json.Unmarshal(body, &root)
+	if err != nil {
+		return PROBE_WRONG_DEVICE, root, fmt.Errorf("Response is not a Redfish service root: %v", err)
+	}
+	if !strings.HasPrefix(root.OdataType, "#ServiceRoot.") || root.RedfishVersion == "" {
+		return PROBE_WRONG_DEVICE, root, fmt.Errorf("Response is not a Redfish service root: @odata.type '%s', RedfishVersion '%s'",
+			root.OdataType, root.RedfishVersion)
+	}
+	if !vendorAllowed(serviceRootVendors, root.Vendor) {
+		return PROBE_WRONG_DEVICE, root, fmt.Errorf("Redfish service root is from vendor '%s', not one of %s",
+			root.Vendor, strings.Join(serviceRootVendors, ", "))
+	}
+	if root.Managers == nil || root.Managers.OdataID == "" || root.Chassis == nil || root.Chassis.OdataID == "" {
+		return PROBE_BOOTING, root, fmt.Errorf("Redfish service root has no Managers or Chassis yet")
+	}
+	return PROBE_PRESENT, root, nil
+}
+
+// recordServiceRoot saves the result of a Redfish ping of an endpoint.
+// The caller must hold ne.HSMPresLock.
+func (ne *NetEndpoint) recordServiceRoot(result string, root RedfishServiceRoot) {
+	ne.serviceRoot.Result = result
+	if result == PROBE_PRESENT {
+		ne.serviceRoot.Vendor = root.Vendor
+		ne.serviceRoot.Product = root.Product
+		ne.serviceRoot.UUID = root.UUID
+		ne.serviceRoot.RedfishVersion = root.RedfishVersion
+	}
+}
@@ -0,0 +1,109 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"testing"
+)
+
+const testServiceRoot = `{
+	"@odata.id": "/redfish/v1/",
+	"@odata.type": "#ServiceRoot.v1_5_0.ServiceRoot",
+	"Id": "RootService",
+	"RedfishVersion": "1.7.0",
+	"UUID": "8a8b6f2e-3c49-4d3b-9a0e-3c6a2d1d0001",
+	"Vendor": "HPE",
+	"Product": "Olympus",
+	"Chassis": {"@odata.id": "/redfish/v1/Chassis"},
+	"Managers": {"@odata.id": "/redfish/v1/Managers"}
+}`
+
+func Test_classifyServiceRoot(t *testing.T) {
+	tests := []struct {
+		description  string
+		status       int
+		body         string
+		expectResult string
+	}{
+		{"Cray BMC", 200, testServiceRoot, PROBE_PRESENT},
+		{"Captive portal", 200, `<html><body>Please log in</body></html>`, PROBE_WRONG_DEVICE},
+		{"Some other JSON API", 200, `{"status": "ok"}`, PROBE_WRONG_DEVICE},
+		{"No RedfishVersion", 200, `{"@odata.type": "#ServiceRoot.v1_5_0.ServiceRoot"}`, PROBE_WRONG_DEVICE},
+		{"Other vendor", 200, `{"@odata.type": "#ServiceRoot.v1_5_0.ServiceRoot", "RedfishVersion": "1.7.0", "Vendor": "Acme",
+			"Chassis": {"@odata.id": "/redfish/v1/Chassis"}, "Managers": {"@odata.id": "/redfish/v1/Managers"}}`, PROBE_WRONG_DEVICE},
+		{"Half booted BMC", 200, `{"@odata.type": "#ServiceRoot.v1_5_0.ServiceRoot", "RedfishVersion": "1.7.0"}`, PROBE_BOOTING},
+		{"Redfish service starting", 503, ``, PROBE_BOOTING},
+		{"Authentication required", 401, ``, PROBE_AUTH_REQUIRED},
+		{"Not found", 404, `not found`, PROBE_BAD_STATUS},
+	}
+
+	for i, test := range tests {
+		result, root, err := classifyServiceRoot(test.status, []byte(test.body))
+		if result != test.expectResult {
+			t.Errorf("Test %v (%s) Failed: Expected %s; Received %s (%v)", i, test.description, test.expectResult, result, err)
+		}
+		if (err == nil) != (result == PROBE_PRESENT) {
+			t.Errorf("Test %v (%s) Failed: Expected an error for anything but present; Received %v", i, test.description, err)
+		}
+		if result == PROBE_PRESENT && (root.Vendor != "HPE" || root.UUID == "") {
+			t.Errorf("Test %v (%s) Failed: Vendor and UUID not parsed; Received %+v", i, test.description, root)
+		}
+	}
+}
+
+func Test_vendorAllowed(t *testing.T) {
+	tests := []struct {
+		description string
+		list        string
+		vendor      string
+		expectOK    bool
+	}{
+		{"Default list", serviceRootVendorList, "HPE", true},
+		{"Different case", serviceRootVendorList, "cray", true},
+		{"Not in the list", serviceRootVendorList, "Acme", false},
+		{"Vendor not reported", serviceRootVendorList, "", true},
+		{"Configured list", " Acme, Other ", "acme", true},
+		{"Configured list without Cray", "Acme", "Cray", false},
+		{"Any vendor", "", "Acme", true},
+	}
+
+	for i, test := range tests {
+		if ok := vendorAllowed(parseVendorList(test.list), test.vendor); ok != test.expectOK {
+			t.Errorf("Test %v (%s) Failed: Expected %v; Received %v", i, test.description, test.expectOK, ok)
+		}
+	}
+}
+
+func Test_recordServiceRoot(t *testing.T) {
+	ne := &NetEndpoint{name: "x9000c1b0"}
+	_, root, _ := classifyServiceRoot(200, []byte(testServiceRoot))
+	ne.recordServiceRoot(PROBE_PRESENT, root)
+	ne.recordServiceRoot(PROBE_ERROR, RedfishServiceRoot{})
+
+	info := ne.serviceRoot
+	if info.Result != PROBE_ERROR || info.UUID != root.UUID || info.Vendor != "HPE" {
+		t.Errorf("Expected the last result with the last known vendor and UUID; Received %+v", info)
+	}
+}
@@ -0,0 +1,69 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// How long MEDS waits for in-flight requests when shutting down.  The MEDS
+// loops and the API requests in progress each get this long.
+var shutdownTimeout = 30 // In seconds
+
+// shutdown is called once the root context has been cancelled.  It first
+// stops taking new API requests and reports not ready, then waits for the
+// MEDS loops to finish what they were doing, and finally stops the HTTP
+// server, giving the API requests in progress their own shutdownTimeout to
+// finish.  Loop work still in flight after shutdownTimeout is cancelled.
+func shutdown(cancelWork context.CancelFunc, done ...<-chan struct{}) {
+	log.Printf("INFO: Shutting down; waiting up to %d seconds for in-flight requests", shutdownTimeout)
+	timeout := time.Duration(shutdownTimeout) * time.Second
+
+	medsHealth.setShuttingDown(true)
+	unsubscribeCtx, cancelUnsubscribe := context.WithTimeout(context.Background(), timeout)
+	unsubscribeSCN(unsubscribeCtx)
+	cancelUnsubscribe()
+
+	deadline, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for _, d := range done {
+		select {
+		case <-d:
+		case <-deadline.Done():
+		}
+	}
+	if deadline.Err() != nil {
+		log.Printf("WARNING: Shutdown timeout reached, cancelling in-flight requests")
+	}
+	cancelWork()
+
+	httpDeadline, cancelHTTP := context.WithTimeout(context.Background(), timeout)
+	defer cancelHTTP()
+	stopHTTPServer(httpDeadline)
+	log.Printf("INFO: MEDS stopped")
+}
@@ -0,0 +1,130 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_shutdown(t *testing.T) {
+	defer func() { shutdownTimeout = 30 }()
+	shutdownTimeout = 1
+
+	tests := []struct {
+		description string
+		finish      bool
+		minWait     time.Duration
+	}{{
+		"Loops finish in time",
+		true,
+		0,
+	}, {
+		"Loops don't finish in time",
+		false,
+		time.Second,
+	}}
+
+	for i, test := range tests {
+		workCtx, cancelWork := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		if test.finish {
+			close(done)
+		}
+
+		start := time.Now()
+		shutdown(cancelWork, done)
+		elapsed := time.Since(start)
+
+		if elapsed < test.minWait || elapsed > test.minWait+500*time.Millisecond {
+			t.Errorf("Test %v (%s) Failed: Unexpected shutdown time %v", i, test.description, elapsed)
+		}
+		if workCtx.Err() == nil {
+			t.Errorf("Test %v (%s) Failed: In-flight requests not cancelled", i, test.description)
+		}
+	}
+}
+
+func Test_shutdown_api(t *testing.T) {
+	defer func() {
+		shutdownTimeout = 30
+		httpServer = nil
+		medsHealth.setShuttingDown(false)
+	}()
+	shutdownTimeout = 1
+
+	// A request that outlasts the wait for the loops, such as a rotation.
+	router := newRouter()
+	router.HandleFunc("GET /slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(1500 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	})
+	testServer := httptest.NewServer(router)
+	defer testServer.Close()
+	httpServer = testServer.Config
+
+	slowResult := make(chan error, 1)
+	go func() {
+		resp, err := http.Get(testServer.URL + "/slow")
+		if err == nil {
+			resp.Body.Close()
+		}
+		slowResult <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	_, cancelWork := context.WithCancel(context.Background())
+	loops := make(chan struct{}) // Never finish
+	stopped := make(chan struct{})
+	go func() {
+		shutdown(cancelWork, loops)
+		close(stopped)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	// While draining, probes still answer but no new API work is taken.
+	for _, test := range []struct {
+		path       string
+		expectCode int
+	}{
+		{"/healthz", http.StatusOK},
+		{"/readyz", http.StatusServiceUnavailable},
+		{"/v1/endpoints", http.StatusServiceUnavailable},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if w.Code != test.expectCode {
+			t.Errorf("Expected %d for %s while shutting down; Received %d", test.expectCode, test.path, w.Code)
+		}
+	}
+
+	if err := <-slowResult; err != nil {
+		t.Errorf("In-flight API request cut off by shutdown: %v", err)
+	}
+	<-stopped
+}
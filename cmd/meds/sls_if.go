@@ -27,6 +27,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -36,14 +37,24 @@ import (
 	base "github.com/Cray-HPE/hms-base/v2"
 	sls_common "github.com/Cray-HPE/hms-sls/v2/pkg/sls-common"
 	"github.com/Cray-HPE/hms-xname/xnametypes"
+	"github.com/hashicorp/go-retryablehttp"
 )
 
+// slsGet does a GET of an SLS API path.
+func slsGet(ctx context.Context, path string) (*http.Response, error) {
+	req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodGet, sls+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return client.InsecureClient.Do(req)
+}
+
 // Query SLS for relevant cabinet info.  We need the cabinet XName,
 // cabinet class (mountain), MACPrefix, IPv4 CIDR, IPv6 prefix.
 // If --sls=xxxx or MEDS_SLS=xxx was not specified, then return
 // nothing, which will cause MEDS to try the older method.
 
-func getSLSCabInfo() ([]sls_common.GenericHardware, error) {
+func getSLSCabInfo(ctx context.Context) ([]sls_common.GenericHardware, error) {
 	var jdata []sls_common.GenericHardware
 	var mcabs []sls_common.GenericHardware
 	var hcabs []sls_common.GenericHardware
@@ -52,7 +63,8 @@ func getSLSCabInfo() ([]sls_common.GenericHardware, error) {
 
 	//Search:  /search/hardware?type=comptype_cabinet&class=Mountain
 
-	rsp, err := client.InsecureClient.Get(sls + "/search/hardware?type=comptype_cabinet&class=Mountain")
+	rsp, err := slsGet(ctx, "/search/hardware?type=comptype_cabinet&class=Mountain")
+	recordRequest("sls", "search_cabinets", rsp, err)
 
 	if err != nil {
 		base.DrainAndCloseResponseBody(rsp)
@@ -86,8 +98,9 @@ func getSLSCabInfo() ([]sls_common.GenericHardware, error) {
 
 	//Search:  /search/hardware?type=comptype_cabinet&class=Hill
 
-	rsp, err = client.InsecureClient.Get(sls + "/search/hardware?type=comptype_cabinet&class=Hill")
+	rsp, err = slsGet(ctx, "/search/hardware?type=comptype_cabinet&class=Hill")
 	defer base.DrainAndCloseResponseBody(rsp)
+	recordRequest("sls", "search_cabinets", rsp, err)
 
 	if err != nil {
 		log.Printf("ERROR in GET of hardware search: %v\n", err)
@@ -128,7 +141,7 @@ func getSLSCabInfo() ([]sls_common.GenericHardware, error) {
 	return jdata, nil
 }
 
-func getSLSCabinetChassis(cabinetXname string) ([]sls_common.GenericHardware, error) {
+func getSLSCabinetChassis(ctx context.Context, cabinetXname string) ([]sls_common.GenericHardware, error) {
 	if xnametypes.GetHMSType(cabinetXname) != xnametypes.Cabinet {
 		return nil, fmt.Errorf("provided xname is not a cabinet: %v", cabinetXname)
 	}
@@ -136,8 +149,9 @@ func getSLSCabinetChassis(cabinetXname string) ([]sls_common.GenericHardware, er
 	// Search:  /search/hardware?type=comptype_chassis&parent=x1000
 	var body []byte
 	var berr error
-	rsp, err := client.InsecureClient.Get(sls + fmt.Sprintf("/search/hardware?type=comptype_chassis&parent=%s", cabinetXname))
+	rsp, err := slsGet(ctx, fmt.Sprintf("/search/hardware?type=comptype_chassis&parent=%s", cabinetXname))
 	defer base.DrainAndCloseResponseBody(rsp)
+	recordRequest("sls", "search_chassis", rsp, err)
 	if err != nil {
 		log.Printf("ERROR in GET of hardware search: %v\n", err)
 		return nil, err
@@ -178,3 +192,45 @@ func getSLSCabinetChassis(cabinetXname string) ([]sls_common.GenericHardware, er
 
 	return cabinetChassis, nil
 }
+
+// getSLSHardwareOfType returns the hardware of one type in SLS, limited to
+// the children of parent unless it is empty.
+func getSLSHardwareOfType(ctx context.Context, hwType sls_common.HMSStringType, parent string) ([]sls_common.GenericHardware, error) {
+	// Search:  /search/hardware?type=comptype_compmod
+	var body []byte
+	var berr error
+	path := fmt.Sprintf("/search/hardware?type=%s", hwType)
+	if parent != "" {
+		path += "&parent=" + parent
+	}
+	rsp, err := slsGet(ctx, path)
+	defer base.DrainAndCloseResponseBody(rsp)
+	recordRequest("sls", "search_hardware", rsp, err)
+	if err != nil {
+		log.Printf("ERROR in GET of hardware search: %v\n", err)
+		return nil, err
+	}
+
+	if rsp.Body != nil {
+		body, berr = ioutil.ReadAll(rsp.Body)
+	}
+
+	if rsp.StatusCode != http.StatusOK {
+		emsg := fmt.Sprintf("Bad error code from hardware SLS /search GET: %d/%s\n",
+			rsp.StatusCode, http.StatusText(rsp.StatusCode))
+		return nil, fmt.Errorf("%s", emsg)
+	}
+
+	if berr != nil {
+		log.Printf("ERROR reading SLS /search response body (for %s): %v\n", hwType, berr)
+		return nil, berr
+	}
+
+	var hardware []sls_common.GenericHardware
+	umerr := json.Unmarshal(body, &hardware)
+	if umerr != nil {
+		log.Printf("ERROR unmarshalling SLS /search response body (for %s): %v\n", hwType, umerr)
+		return nil, umerr
+	}
+	return hardware, nil
+}
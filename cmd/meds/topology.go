@@ -0,0 +1,206 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"sort"
+
+	sls_common "github.com/Cray-HPE/hms-sls/v2/pkg/sls-common"
+)
+
+// A Topology describes which BMCs a chassis can hold, besides its own
+// chassis BMC.
+type Topology struct {
+	Name        string `json:"Name"`
+	Slots       []int  `json:"Slots"`       // Compute slots
+	NodeBMCs    []int  `json:"NodeBMCs"`    // BMC indices on each node card
+	SwitchCards []int  `json:"SwitchCards"` // Switch card (router) slots
+}
+
+// TopologyFile is the format of -topology-file: extra or replacement
+// topologies, and which topology each class of cabinet uses.
+type TopologyFile struct {
+	Topologies []Topology        `json:"Topologies"`
+	Classes    map[string]string `json:"Classes"`
+}
+
+const DEFAULT_TOPOLOGY = "mountain"
+
+// File to load topologies from, if any.
+var topologyFile = ""
+
+// Known topologies by name, and the topology for each class of cabinet
+// that doesn't name one in SLS.  Only changed at startup.
+var topologies = map[string]*Topology{
+	"mountain": {
+		Name:        "mountain",
+		Slots:       intRange(MTN_SLOT_COUNT),
+		NodeBMCs:    intRange(MTN_nC_PER_SLOT),
+		SwitchCards: intRange(MTN_SWITCH_COUNT),
+	},
+}
+
+// Hill cabinets get the same BMCs MEDS has always generated for them, as
+// Mountain ones do; use a topology file for chassis laid out differently.
+var topologiesByClass = map[string]string{
+	string(sls_common.ClassMountain): "mountain",
+	string(sls_common.ClassHill):     "mountain",
+}
+
+// intRange returns 0 through n-1.
+func intRange(n int) []int {
+	r := make([]int, n)
+	for i := range r {
+		r[i] = i
+	}
+	return r
+}
+
+// validate checks that a topology doesn't list a position twice or a
+// negative position.
+func (t *Topology) validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("topology has no name")
+	}
+	for field, positions := range map[string][]int{
+		"Slots":       t.Slots,
+		"NodeBMCs":    t.NodeBMCs,
+		"SwitchCards": t.SwitchCards,
+	} {
+		seen := make(map[int]bool)
+		for _, p := range positions {
+			if p < 0 || seen[p] {
+				return fmt.Errorf("topology %s has a bad or repeated entry %d in %s", t.Name, p, field)
+			}
+			seen[p] = true
+		}
+	}
+	return nil
+}
+
+// loadTopologyFile adds the topologies in a topology file to the known
+// topologies, replacing any with the same name, and applies its class
+// mappings.
+func loadTopologyFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var tf TopologyFile
+	err = json.Unmarshal(data, &tf)
+	if err != nil {
+		return fmt.Errorf("unable to parse topology file %s: %v", path, err)
+	}
+
+	for i := range tf.Topologies {
+		t := tf.Topologies[i]
+		err = t.validate()
+		if err != nil {
+			return err
+		}
+		topologies[t.Name] = &t
+	}
+	for class, name := range tf.Classes {
+		if _, ok := topologies[name]; !ok {
+			return fmt.Errorf("class %s uses unknown topology '%s'", class, name)
+		}
+		topologiesByClass[class] = name
+	}
+
+	names := make([]string, 0, len(topologies))
+	for name := range topologies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	log.Printf("INFO: Loaded topology file %s; known topologies: %v", path, names)
+	return nil
+}
+
+// slsTopology returns the topology named by, or given inline in, the
+// "Topology" key of SLS ExtraProperties, or nil if there is none.
+func slsTopology(xname string, extraProperties interface{}) (*Topology, error) {
+	var extra struct {
+		Topology json.RawMessage `json:"Topology,omitempty"`
+	}
+	raw, err := json.Marshal(extraProperties)
+	if err == nil {
+		err = json.Unmarshal(raw, &extra)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("INTERNAL ERROR, can't read ExtraProperties of %s: %v", xname, err)
+	}
+	if len(extra.Topology) == 0 || string(extra.Topology) == "null" {
+		return nil, nil
+	}
+
+	var name string
+	if json.Unmarshal(extra.Topology, &name) == nil {
+		t, ok := topologies[name]
+		if !ok {
+			return nil, fmt.Errorf("%s uses unknown topology '%s'", xname, name)
+		}
+		return t, nil
+	}
+
+	t := new(Topology)
+	err = json.Unmarshal(extra.Topology, t)
+	if err != nil {
+		return nil, fmt.Errorf("%s has a bad topology in SLS: %v", xname, err)
+	}
+	if t.Name == "" {
+		t.Name = xname
+	}
+	err = t.validate()
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// chassisTopology works out the topology of a chassis.  A topology in the
+// chassis' SLS ExtraProperties wins over one in the cabinet's, which wins
+// over the one for the cabinet's class.
+func chassisTopology(cabinet, chassis sls_common.GenericHardware) (*Topology, error) {
+	for _, hw := range []sls_common.GenericHardware{chassis, cabinet} {
+		t, err := slsTopology(hw.Xname, hw.ExtraPropertiesRaw)
+		if err != nil || t != nil {
+			return t, err
+		}
+	}
+
+	name, ok := topologiesByClass[string(cabinet.Class)]
+	if !ok {
+		name = DEFAULT_TOPOLOGY
+	}
+	t, ok := topologies[name]
+	if !ok {
+		return nil, fmt.Errorf("cabinet %s uses unknown topology '%s'", cabinet.Xname, name)
+	}
+	return t, nil
+}
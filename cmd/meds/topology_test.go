@@ -0,0 +1,158 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	sls_common "github.com/Cray-HPE/hms-sls/v2/pkg/sls-common"
+)
+
+// saveTopologies restores the known topologies when a test is done.
+func saveTopologies(t *testing.T) {
+	savedTopologies := make(map[string]*Topology)
+	for k, v := range topologies {
+		savedTopologies[k] = v
+	}
+	savedClasses := make(map[string]string)
+	for k, v := range topologiesByClass {
+		savedClasses[k] = v
+	}
+	t.Cleanup(func() {
+		topologies = savedTopologies
+		topologiesByClass = savedClasses
+	})
+}
+
+func Test_chassisTopology(t *testing.T) {
+	saveTopologies(t)
+	topologies["small"] = &Topology{Name: "small", Slots: []int{0, 1}, NodeBMCs: []int{0}, SwitchCards: []int{0}}
+
+	cabinet := func(class sls_common.CabinetType, extra map[string]interface{}) sls_common.GenericHardware {
+		return sls_common.GenericHardware{Xname: "x9000", Class: class, ExtraPropertiesRaw: extra}
+	}
+	chassis := func(extra map[string]interface{}) sls_common.GenericHardware {
+		return sls_common.GenericHardware{Xname: "x9000c1", Parent: "x9000", ExtraPropertiesRaw: extra}
+	}
+
+	tests := []struct {
+		description    string
+		cabinet        sls_common.GenericHardware
+		chassis        sls_common.GenericHardware
+		expectTopology string
+		expectErr      bool
+	}{{
+		"Mountain cabinet",
+		cabinet(sls_common.ClassMountain, nil),
+		chassis(nil),
+		"mountain",
+		false,
+	}, {
+		"Hill cabinet",
+		cabinet(sls_common.ClassHill, nil),
+		chassis(nil),
+		"mountain",
+		false,
+	}, {
+		"Topology named by the cabinet",
+		cabinet(sls_common.ClassHill, map[string]interface{}{"Topology": "small"}),
+		chassis(nil),
+		"small",
+		false,
+	}, {
+		"Chassis topology wins over the cabinet's",
+		cabinet(sls_common.ClassHill, map[string]interface{}{"Topology": "small"}),
+		chassis(map[string]interface{}{"Topology": map[string]interface{}{
+			"Slots": []int{0, 1, 2, 3}, "NodeBMCs": []int{0, 1}, "SwitchCards": []int{0, 1},
+		}}),
+		"x9000c1",
+		false,
+	}, {
+		"Unknown topology",
+		cabinet(sls_common.ClassMountain, map[string]interface{}{"Topology": "bogus"}),
+		chassis(nil),
+		"",
+		true,
+	}, {
+		"Repeated slot",
+		cabinet(sls_common.ClassMountain, nil),
+		chassis(map[string]interface{}{"Topology": map[string]interface{}{"Slots": []int{0, 0}}}),
+		"",
+		true,
+	}}
+
+	for i, test := range tests {
+		topology, err := chassisTopology(test.cabinet, test.chassis)
+		if test.expectErr {
+			if err == nil {
+				t.Errorf("Test %v (%s) Failed: Expected an error; Received %+v", i, test.description, topology)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %v (%s) Failed: Unexpected error: %v", i, test.description, err)
+		} else if topology.Name != test.expectTopology {
+			t.Errorf("Test %v (%s) Failed: Expected topology %s; Received %s", i, test.description, test.expectTopology, topology.Name)
+		}
+	}
+}
+
+func Test_loadTopologyFile(t *testing.T) {
+	saveTopologies(t)
+
+	path := filepath.Join(t.TempDir(), "topology.json")
+	os.WriteFile(path, []byte(`{
+		"Topologies": [{"Name": "half", "Slots": [0, 1, 2, 3], "NodeBMCs": [0, 1], "SwitchCards": [0, 1, 2, 3]}],
+		"Classes": {"Hill": "half"}
+	}`), 0644)
+
+	err := loadTopologyFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	cabinet := testPlanCabinet
+	cabinet.Class = sls_common.ClassHill
+	endpoints, err := chassisEndpoints(cabinet, testPlanChassis, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// One chassis BMC, four switch card BMCs and two BMCs in each of four slots
+	if len(endpoints) != 1+4+4*2 {
+		t.Errorf("Expected %d endpoints; Received %d", 1+4+4*2, len(endpoints))
+	}
+	for _, ne := range endpoints {
+		if ne.name == "x9000c1s4b0" || ne.name == "x9000c1r4b0" {
+			t.Errorf("Unexpected endpoint %s", ne.name)
+		}
+	}
+
+	os.WriteFile(path, []byte(`{"Classes": {"Hill": "bogus"}}`), 0644)
+	if loadTopologyFile(path) == nil {
+		t.Errorf("Expected an error for an unknown topology")
+	}
+}
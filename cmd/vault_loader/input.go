@@ -0,0 +1,235 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	compcreds "github.com/Cray-HPE/hms-compcredentials"
+	"github.com/Cray-HPE/hms-xname/xnametypes"
+
+	"github.com/Cray-HPE/hms-meds/internal/model"
+)
+
+// decodeStrict unmarshals JSON, rejecting fields v doesn't have and
+// anything after the first value.
+func decodeStrict(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(v)
+	if err != nil {
+		return fmt.Errorf("invalid JSON: %v", err)
+	}
+	if decoder.More() {
+		return fmt.Errorf("invalid JSON: unexpected data after the credentials")
+	}
+	return nil
+}
+
+// parseCredentials parses and validates global, or cabinet, chassis, etc.,
+// BMC credentials.
+func parseCredentials(data []byte) (model.MedsCredentials, error) {
+	var credentials model.MedsCredentials
+	err := decodeStrict(data, &credentials)
+	if err != nil {
+		return credentials, err
+	}
+	if credentials.Username == "" || credentials.Password == "" {
+		return credentials, fmt.Errorf("credentials need both a Username and a Password")
+	}
+	return credentials, nil
+}
+
+// parseDefaultCredentials parses VAULT_REDFISH_DEFAULTS as vault_loader
+// always has, without the checks the subcommands make, so that existing
+// deployments keep loading whatever they have set.
+func parseDefaultCredentials(data []byte) (model.MedsCredentials, error) {
+	var credentials model.MedsCredentials
+	err := json.Unmarshal(data, &credentials)
+	return credentials, err
+}
+
+// parseSSHCredentials parses and validates BMC SSH credentials.
+func parseSSHCredentials(data []byte) (model.MedsSSHCredentials, error) {
+	var sshCreds model.MedsSSHCredentials
+	err := decodeStrict(data, &sshCreds)
+	if err != nil {
+		return sshCreds, err
+	}
+	if sshCreds.Username == "" {
+		return sshCreds, fmt.Errorf("SSH credentials need a username")
+	}
+	if sshCreds.Password == "" && sshCreds.AuthorizedKey == "" {
+		return sshCreds, fmt.Errorf("SSH credentials need a password or an authorizedkey")
+	}
+	if sshCreds.AuthorizedKey != "" && len(strings.Fields(sshCreds.AuthorizedKey)) < 2 {
+		return sshCreds, fmt.Errorf("authorizedkey is not an SSH public key")
+	}
+	return sshCreds, nil
+}
+
+// sshTarget checks the xname of BMC SSH credentials and describes which
+// credentials they are.
+func sshTarget(xname string) (string, error) {
+	if xname == "" {
+		return "global BMC SSH credentials", nil
+	}
+	if err := checkXname(xname); err != nil {
+		return "", err
+	}
+	return "BMC SSH credentials of " + xname, nil
+}
+
+// checkXname checks that an xname is valid and normalized.
+func checkXname(xname string) error {
+	if xnametypes.NormalizeHMSCompID(xname) != xname || !xnametypes.IsHMSCompIDValid(xname) {
+		return fmt.Errorf("'%s' is not a valid normalized xname", xname)
+	}
+	return nil
+}
+
+// shadowedXnames lists the xnames in keys that are xname or under it.
+// MEDS only resolves credentials for BMCs that have none of their own, so
+// credentials set for xname don't apply to these.
+func shadowedXnames(xname string, keys []string) []string {
+	xnames := make([]string, 0)
+	for _, key := range keys {
+		for x := key; x != ""; x = xnametypes.GetHMSCompParent(x) {
+			if x == xname {
+				xnames = append(xnames, key)
+				break
+			}
+		}
+	}
+	sort.Strings(xnames)
+	return xnames
+}
+
+// importFormat works out whether a file to import is CSV or JSON: from
+// format if it is given, then the file name, then the contents.
+func importFormat(file, format string, data []byte) string {
+	if format != "" {
+		return strings.ToLower(format)
+	}
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".csv":
+		return "csv"
+	case ".json":
+		return "json"
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		return "json"
+	}
+	return "csv"
+}
+
+// parseCompCredentials parses and validates per-xname BMC credentials to
+// import.  JSON is a list of objects with xname, username and password
+// fields; CSV has a header row naming the xname, username and password
+// columns.
+func parseCompCredentials(data []byte, format string) ([]compcreds.CompCredentials, error) {
+	var creds []compcreds.CompCredentials
+	var err error
+	switch format {
+	case "json":
+		err = decodeStrict(data, &creds)
+	case "csv":
+		creds, err = parseCompCredentialsCSV(data)
+	default:
+		err = fmt.Errorf("unknown format '%s'", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(creds) == 0 {
+		return nil, fmt.Errorf("no credentials to import")
+	}
+
+	seen := make(map[string]bool)
+	for i, cred := range creds {
+		xname := xnametypes.NormalizeHMSCompID(cred.Xname)
+		if !xnametypes.IsHMSCompIDValid(xname) {
+			return nil, fmt.Errorf("entry %d: '%s' is not a valid xname", i+1, cred.Xname)
+		}
+		if cred.Username == "" || cred.Password == "" {
+			return nil, fmt.Errorf("entry %d (%s): needs both a username and a password", i+1, xname)
+		}
+		if seen[xname] {
+			return nil, fmt.Errorf("entry %d: %s is listed more than once", i+1, xname)
+		}
+		seen[xname] = true
+		creds[i].Xname = xname
+	}
+	return creds, nil
+}
+
+func parseCompCredentialsCSV(data []byte) ([]compcreds.CompCredentials, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %v", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"xname", "username", "password"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("invalid CSV: no %s column", name)
+		}
+	}
+
+	creds := make([]compcreds.CompCredentials, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+		creds = append(creds, compcreds.CompCredentials{
+			Xname:    record[columns["xname"]],
+			Username: record[columns["username"]],
+			Password: record[columns["password"]],
+		})
+	}
+	return creds, nil
+}
+
+// redactCompCredentials describes per-xname BMC credentials without the
+// password.
+func redactCompCredentials(cred compcreds.CompCredentials) string {
+	return fmt.Sprintf("Username: %s, Password: <REDACTED>", cred.Username)
+}
@@ -0,0 +1,181 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_parseCredentials(t *testing.T) {
+	tests := []struct {
+		input     string
+		expectErr bool
+	}{
+		{`{"Username":"root","Password":"initial0"}`, false},
+		{`{"Username":"root"}`, true},
+		{`{"Username":"root","Password":"initial0","Passwrd":"typo"}`, true},
+		{`{"Username":"root","Password":"initial0"} {}`, true},
+		{`not json`, true},
+	}
+	for i, test := range tests {
+		_, err := parseCredentials([]byte(test.input))
+		if (err != nil) != test.expectErr {
+			t.Errorf("Test %v Failed: Expected error %v for %s; Received %v", i, test.expectErr, test.input, err)
+		}
+	}
+}
+
+func Test_parseDefaultCredentials(t *testing.T) {
+	tests := []struct {
+		input     string
+		expectErr bool
+	}{
+		{`{"Username":"root","Password":"initial0"}`, false},
+		{`{"Username":"root"}`, false},
+		{`{"Username":"root","Password":"initial0","Extra":"ignored"}`, false},
+		{`not json`, true},
+	}
+	for i, test := range tests {
+		_, err := parseDefaultCredentials([]byte(test.input))
+		if (err != nil) != test.expectErr {
+			t.Errorf("Test %v Failed: Expected error %v for %s; Received %v", i, test.expectErr, test.input, err)
+		}
+	}
+}
+
+func Test_parseSSHCredentials(t *testing.T) {
+	tests := []struct {
+		input     string
+		expectErr bool
+	}{
+		{`{"username":"root","password":"initial0"}`, false},
+		{`{"username":"root","authorizedkey":"ssh-ed25519 AAAAC3Nza root@ncn-m001"}`, false},
+		{`{"username":"root"}`, true},
+		{`{"password":"initial0"}`, true},
+		{`{"username":"root","authorizedkey":"AAAAC3Nza"}`, true},
+	}
+	for i, test := range tests {
+		_, err := parseSSHCredentials([]byte(test.input))
+		if (err != nil) != test.expectErr {
+			t.Errorf("Test %v Failed: Expected error %v for %s; Received %v", i, test.expectErr, test.input, err)
+		}
+	}
+}
+
+func Test_shadowedXnames(t *testing.T) {
+	keys := []string{"x1000c3s5b0", "x1000c3s5b1", "x1000c3b0", "x1000c30b0", "x1001c3s5b0"}
+	tests := []struct {
+		xname        string
+		expectXnames []string
+	}{
+		{"x1000", []string{"x1000c30b0", "x1000c3b0", "x1000c3s5b0", "x1000c3s5b1"}},
+		{"x1000c3", []string{"x1000c3b0", "x1000c3s5b0", "x1000c3s5b1"}},
+		{"x1000c3s5", []string{"x1000c3s5b0", "x1000c3s5b1"}},
+		{"x1000c3s4", []string{}},
+	}
+	for i, test := range tests {
+		xnames := shadowedXnames(test.xname, keys)
+		if strings.Join(xnames, ",") != strings.Join(test.expectXnames, ",") {
+			t.Errorf("Test %v Failed: Expected %v under %s; Received %v", i, test.expectXnames, test.xname, xnames)
+		}
+	}
+}
+
+func Test_parseCompCredentials(t *testing.T) {
+	tests := []struct {
+		description  string
+		file         string
+		input        string
+		expectErr    bool
+		expectXnames []string
+	}{{
+		"CSV",
+		"creds.csv",
+		"Xname,Username,Password\nx1000c0s0b0,root,initial0\nX1000C0S1B0,root,initial1\n",
+		false,
+		[]string{"x1000c0s0b0", "x1000c0s1b0"},
+	}, {
+		"CSV with columns in another order",
+		"creds",
+		"password, xname, username\ninitial0, x1000c0s0b0, root\n",
+		false,
+		[]string{"x1000c0s0b0"},
+	}, {
+		"JSON",
+		"creds",
+		`[{"xname":"x1000c0s0b0","username":"root","password":"initial0"}]`,
+		false,
+		[]string{"x1000c0s0b0"},
+	}, {
+		"CSV missing a column",
+		"creds.csv",
+		"xname,username\nx1000c0s0b0,root\n",
+		true,
+		nil,
+	}, {
+		"Invalid xname",
+		"creds.csv",
+		"xname,username,password\nnode1,root,initial0\n",
+		true,
+		nil,
+	}, {
+		"Missing password",
+		"creds.json",
+		`[{"xname":"x1000c0s0b0","username":"root"}]`,
+		true,
+		nil,
+	}, {
+		"Duplicate xname",
+		"creds.csv",
+		"xname,username,password\nx1000c0s0b0,root,initial0\nx1000c0s0b0,root,initial1\n",
+		true,
+		nil,
+	}, {
+		"Empty",
+		"creds.json",
+		`[]`,
+		true,
+		nil,
+	}}
+
+	for i, test := range tests {
+		creds, err := parseCompCredentials([]byte(test.input), importFormat(test.file, "", []byte(test.input)))
+		if (err != nil) != test.expectErr {
+			t.Errorf("Test %v (%s) Failed: Expected error %v; Received %v", i, test.description, test.expectErr, err)
+			continue
+		}
+		if len(creds) != len(test.expectXnames) {
+			t.Errorf("Test %v (%s) Failed: Expected %v credentials; Received %v",
+				i, test.description, len(test.expectXnames), len(creds))
+			continue
+		}
+		for j, cred := range creds {
+			if cred.Xname != test.expectXnames[j] || cred.Username != "root" || cred.Password == "" {
+				t.Errorf("Test %v (%s) Failed: Unexpected credentials %#v", i, test.description, cred)
+			}
+		}
+	}
+}
@@ -25,52 +25,374 @@
 package main
 
 import (
-	"encoding/json"
+	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"time"
 
-	"github.com/Cray-HPE/hms-meds/internal/model"
+	compcreds "github.com/Cray-HPE/hms-compcredentials"
 	securestorage "github.com/Cray-HPE/hms-securestorage"
+
+	"github.com/Cray-HPE/hms-meds/internal/model"
 )
 
-func main() {
-	var secureStorage securestorage.SecureStorage
-	var credStorage *model.MedsCredStore
+const usage = `Usage: vault_loader [<command> [flags]]
 
-	defaultCredentials, ok := os.LookupEnv("VAULT_REDFISH_DEFAULTS")
-	if !ok {
-		panic("Value not set for VAULT_REDFISH_DEFAULTS")
-	}
+With no command, loads the global BMC credentials from the
+VAULT_REDFISH_DEFAULTS environment variable into Vault.
+
+Commands:
+  global show              Show the global BMC credentials (redacted)
+  global set [-file F]     Set the global BMC credentials from JSON
+  global delete            Delete the global BMC credentials
+  bmc show -xname X        Show the BMC credentials for X and everything under it (redacted)
+  bmc set -xname X [-file F]
+                           Set the BMC credentials for X and everything under it from JSON,
+                           listing the BMCs under X that keep their own credentials
+  bmc delete -xname X      Delete the BMC credentials for X and everything under it
+  ssh show [-xname X]      Show the global BMC SSH credentials, or those for X and
+                           everything under it (redacted)
+  ssh set [-xname X] [-file F]
+                           Set the global BMC SSH credentials, or those for X, from JSON
+  ssh delete [-xname X]    Delete the global BMC SSH credentials, or those for X
+  ssh list                 List the xnames with their own BMC SSH credentials
+  resolve -xname X         Show the BMC and SSH credentials MEDS would use for X (redacted)
+                           and where in Vault they come from
+  import -file F [-format csv|json]
+                           Import per-xname BMC credentials from a CSV or JSON file
+
+JSON is read from standard input unless -file is given.  Every command that
+writes to Vault takes -dry-run to validate its input and show what would be
+written without connecting to Vault.
+`
+
+// The Vault path of the per-xname BMC credentials, as used by MEDS.
+const compCredPath = "hms-creds"
+
+// Vault, and the MEDS and component credential stores in it.
+type vaultStores struct {
+	credStorage *model.MedsCredStore
+	hcs         *compcreds.CompCredStore
+}
 
-	// Setup Vault. It's kind of a big deal, so we'll wait forever for this to work.
+// connectVault connects to Vault.  It's kind of a big deal, so we'll wait
+// forever for this to work.
+func connectVault() vaultStores {
 	fmt.Println("Connecting to Vault...")
 	for {
-		var err error
 		// Start a connection to Vault
-		if secureStorage, err = securestorage.NewVaultAdapter("secret"); err != nil {
+		secureStorage, err := securestorage.NewVaultAdapter("secret")
+		if err != nil {
 			fmt.Printf("Unable to connect to Vault (%s)...trying again in 5 seconds.\n", err)
 			time.Sleep(5 * time.Second)
+			continue
+		}
+		fmt.Println("Connected to Vault.")
+		return vaultStores{
+			credStorage: model.NewMedsCredStore(model.CredentialsKeyPrefix, secureStorage),
+			hcs:         compcreds.NewCompCredStore(compCredPath, secureStorage),
+		}
+	}
+}
+
+// readInput reads a file, or standard input if file is "-".
+func readInput(file string) ([]byte, error) {
+	if file == "-" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(file)
+}
+
+// loadDefaults is what vault_loader has always done: store the global
+// credentials from VAULT_REDFISH_DEFAULTS.
+func loadDefaults() error {
+	defaultCredentials, ok := os.LookupEnv("VAULT_REDFISH_DEFAULTS")
+	if !ok {
+		return fmt.Errorf("Value not set for VAULT_REDFISH_DEFAULTS")
+	}
+	credentials, err := parseDefaultCredentials([]byte(defaultCredentials))
+	if err != nil {
+		return err
+	}
+
+	vault := connectVault()
+	return vault.credStorage.StoreGlobalCredentials(credentials)
+}
+
+func globalCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("global needs a subcommand: show, set or delete")
+	}
+	flags := flag.NewFlagSet("global "+args[0], flag.ExitOnError)
+	file := flags.String("file", "-", "JSON file of the credentials")
+	dryRun := flags.Bool("dry-run", false, "Validate and show what would be written without writing it")
+	flags.Parse(args[1:])
+
+	switch args[0] {
+	case "show":
+		credentials, err := connectVault().credStorage.FindGlobalCredentials()
+		if err != nil {
+			return err
+		}
+		fmt.Println(credentials)
+	case "set":
+		data, err := readInput(*file)
+		if err != nil {
+			return err
+		}
+		credentials, err := parseCredentials(data)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Setting the global BMC credentials to %s\n", credentials)
+		if *dryRun {
+			return nil
+		}
+		return connectVault().credStorage.StoreGlobalCredentials(credentials)
+	case "delete":
+		fmt.Println("Deleting the global BMC credentials")
+		if *dryRun {
+			return nil
+		}
+		return connectVault().credStorage.DeleteGlobalCredentials()
+	default:
+		return fmt.Errorf("unknown global subcommand '%s'", args[0])
+	}
+	return nil
+}
+
+func bmcCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("bmc needs a subcommand: show, set or delete")
+	}
+	flags := flag.NewFlagSet("bmc "+args[0], flag.ExitOnError)
+	xname := flags.String("xname", "", "Cabinet, chassis, etc. whose BMC credentials to use")
+	file := flags.String("file", "-", "JSON file of the credentials")
+	dryRun := flags.Bool("dry-run", false, "Validate and show what would be written without writing it")
+	flags.Parse(args[1:])
+
+	if *xname == "" {
+		return fmt.Errorf("bmc %s needs -xname", args[0])
+	}
+	if err := checkXname(*xname); err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "show":
+		credentials, err := connectVault().credStorage.FindBMCCredentials(*xname)
+		if err != nil {
+			return err
+		}
+		fmt.Println(credentials)
+	case "set":
+		data, err := readInput(*file)
+		if err != nil {
+			return err
+		}
+		credentials, err := parseCredentials(data)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Setting the BMC credentials for %s to %s\n", *xname, credentials)
+		if *dryRun {
+			return nil
+		}
+		vault := connectVault()
+		err = vault.credStorage.StoreBMCCredentials(*xname, credentials)
+		if err != nil {
+			return err
+		}
+
+		// BMCs that already have their own credentials keep using them.
+		keys, err := model.LookupKeys(vault.credStorage.SS, compCredPath)
+		if err != nil {
+			return fmt.Errorf("unable to list the BMCs with their own credentials: %v", err)
+		}
+		shadowed := shadowedXnames(*xname, keys)
+		if len(shadowed) > 0 {
+			fmt.Printf("These have their own credentials in %s, which MEDS keeps using instead;\n"+
+				"update them with 'vault_loader import' if they should change too:\n", compCredPath)
+			for _, x := range shadowed {
+				fmt.Printf("  %s\n", x)
+			}
+		}
+	case "delete":
+		fmt.Printf("Deleting the BMC credentials for %s\n", *xname)
+		if *dryRun {
+			return nil
+		}
+		return connectVault().credStorage.DeleteBMCCredentials(*xname)
+	default:
+		return fmt.Errorf("unknown bmc subcommand '%s'", args[0])
+	}
+	return nil
+}
+
+func sshCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("ssh needs a subcommand: show, set, delete or list")
+	}
+	flags := flag.NewFlagSet("ssh "+args[0], flag.ExitOnError)
+	xname := flags.String("xname", "", "BMC whose SSH credentials to use instead of the global ones")
+	file := flags.String("file", "-", "JSON file of the SSH credentials")
+	dryRun := flags.Bool("dry-run", false, "Validate and show what would be written without writing it")
+	flags.Parse(args[1:])
+
+	target, err := sshTarget(*xname)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "show":
+		sshCreds, err := connectVault().credStorage.FindBMCSSHCredentials(*xname)
+		if err != nil {
+			return err
+		}
+		fmt.Println(sshCreds)
+	case "set":
+		data, err := readInput(*file)
+		if err != nil {
+			return err
+		}
+		sshCreds, err := parseSSHCredentials(data)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Setting the %s to %s\n", target, sshCreds)
+		if *dryRun {
+			return nil
+		}
+		return connectVault().credStorage.StoreBMCSSHCredentials(*xname, sshCreds)
+	case "delete":
+		fmt.Printf("Deleting the %s\n", target)
+		if *dryRun {
+			return nil
+		}
+		return connectVault().credStorage.DeleteBMCSSHCredentials(*xname)
+	case "list":
+		xnames, err := connectVault().credStorage.ListBMCSSHCredentialXnames()
+		if err != nil {
+			return err
+		}
+		for _, x := range xnames {
+			fmt.Println(x)
+		}
+	default:
+		return fmt.Errorf("unknown ssh subcommand '%s'", args[0])
+	}
+	return nil
+}
+
+func resolveCommand(args []string) error {
+	flags := flag.NewFlagSet("resolve", flag.ExitOnError)
+	xname := flags.String("xname", "", "BMC whose credentials to resolve")
+	flags.Parse(args)
+
+	if *xname == "" {
+		return fmt.Errorf("resolve needs -xname")
+	}
+	if err := checkXname(*xname); err != nil {
+		return err
+	}
+
+	vault := connectVault()
+	cred, err := vault.hcs.GetCompCred(*xname)
+	if err != nil {
+		return err
+	}
+	if cred.Username != "" {
+		fmt.Printf("BMC credentials: %s (from %s/%s)\n", redactCompCredentials(cred), vault.hcs.CCPath, *xname)
+	} else {
+		credentials, source, err := vault.credStorage.ResolveBMCCredentials(*xname)
+		if err != nil {
+			return err
+		}
+		if source == "" {
+			fmt.Println("BMC credentials: none; MEDS would use its defaults")
 		} else {
-			fmt.Println("Connected to Vault.")
-			credStorage = model.NewMedsCredStore(model.CredentialsKeyPrefix, secureStorage)
-			break
+			fmt.Printf("BMC credentials: %s (from %s, not yet seeded)\n", credentials, source)
 		}
 	}
 
-	var credentials model.MedsCredentials
-	err := json.Unmarshal([]byte(defaultCredentials), &credentials)
+	sshCreds, source, err := vault.credStorage.ResolveBMCSSHCredentials(*xname)
 	if err != nil {
-		panic(err)
+		return err
 	}
+	if source == "" {
+		fmt.Println("BMC SSH credentials: none; MEDS would use its defaults")
+	} else {
+		fmt.Printf("BMC SSH credentials: %s (from %s)\n", sshCreds, source)
+	}
+	return nil
+}
 
-	// Uncomment the following lines if you want to debug what is getting put into Vault.
-	//prettyCredentials, _ := json.MarshalIndent(credentials, "\t", "   ")
-	//fmt.Printf("Loading:\n\t%s\n\n", prettyCredentials)
+func importCommand(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	file := flags.String("file", "", "CSV or JSON file of per-xname credentials")
+	format := flags.String("format", "", "Format of the file, 'csv' or 'json' (default from the file name)")
+	dryRun := flags.Bool("dry-run", false, "Validate and show what would be written without writing it")
+	flags.Parse(args)
 
-	err = credStorage.StoreGlobalCredentials(credentials)
+	if *file == "" {
+		return fmt.Errorf("import needs -file")
+	}
+	data, err := readInput(*file)
+	if err != nil {
+		return err
+	}
+	creds, err := parseCompCredentials(data, importFormat(*file, *format, data))
+	if err != nil {
+		return err
+	}
+
+	// Everything is validated before anything is written.
+	var vault vaultStores
+	if !*dryRun {
+		vault = connectVault()
+	}
+	for _, cred := range creds {
+		fmt.Printf("Setting the credentials of %s to %s\n", cred.Xname, redactCompCredentials(cred))
+		if *dryRun {
+			continue
+		}
+		err = vault.hcs.StoreCompCred(cred)
+		if err != nil {
+			return fmt.Errorf("unable to store the credentials of %s: %v", cred.Xname, err)
+		}
+	}
+	return nil
+}
+
+func main() {
+	var err error
+	if len(os.Args) < 2 {
+		err = loadDefaults()
+	} else {
+		switch os.Args[1] {
+		case "global":
+			err = globalCommand(os.Args[2:])
+		case "bmc":
+			err = bmcCommand(os.Args[2:])
+		case "ssh":
+			err = sshCommand(os.Args[2:])
+		case "resolve":
+			err = resolveCommand(os.Args[2:])
+		case "import":
+			err = importCommand(os.Args[2:])
+		case "help", "-h", "-help", "--help":
+			fmt.Print(usage)
+			return
+		default:
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(2)
+		}
+	}
 	if err != nil {
-		panic(err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
 
 	fmt.Println("Done.")
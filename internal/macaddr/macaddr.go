@@ -0,0 +1,111 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package macaddr generates the locally administered MAC addresses MEDS
+// assigns to Mountain BMCs, and decodes them back into xnames.
+//
+// With the default scheme a MEDS MAC address is prefix:RR:RR:CC:SS:II,
+// where RRRR is the cabinet (rack) number, CC the chassis, SS the slot
+// plus an offset that depends on the kind of BMC, and II the BMC index in
+// the high nibble.  Other layouts are provided by a MACScheme.
+package macaddr
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// DefaultPrefix is the first octet of MEDS MAC addresses unless SLS
+// overrides it for a cabinet.
+const DefaultPrefix = "02"
+
+// The kinds of BMC a MEDS MAC address can encode.
+const (
+	TypeChassisController = "cC"
+	TypeSwitchController  = "sC"
+	TypeNodeController    = "nC"
+)
+
+// Slot offsets for each kind of BMC.
+const (
+	nodeControllerOffset   = 48
+	switchControllerOffset = 96
+)
+
+func GenerateMAC(mp string, rack int, chassis int, slt int, idx int) string {
+	return fmt.Sprintf("%s:%02X:%02X:%02X:%02X:%02X", mp,
+		(rack>>8)&0xFF, rack&0xFF, chassis&0xFF, slt&0xFF, (idx<<4)&0xFF)
+}
+
+func GenerateMACnC(mp string, rack int, chassis int, slt int, idx int) string {
+	return GenerateMAC(mp, rack, chassis, slt+nodeControllerOffset, idx)
+}
+
+func GenerateMACsC(mp string, rack int, chassis int, slt int) string {
+	return GenerateMAC(mp, rack, chassis, slt+switchControllerOffset, 0)
+}
+
+func GenerateMACcC(mp string, rack int, chassis int) string {
+	return GenerateMAC(mp, rack, chassis, 0, 0)
+}
+
+// Normalize strips the separators from a MAC address and lowercases it,
+// which is how HSM keys EthernetInterfaces.
+func Normalize(mac string) string {
+	mac = strings.ReplaceAll(mac, ":", "")
+	mac = strings.ReplaceAll(mac, "-", "")
+	mac = strings.ReplaceAll(mac, ".", "")
+	return strings.ToLower(mac)
+}
+
+// ParseMEDSMAC decodes a MAC address generated by MEDS with the given
+// prefix, returning the xname of the BMC and its type (cC, sC or nC).  An
+// error is returned for anything MEDS wouldn't have generated.
+func ParseMEDSMAC(prefix, mac string) (xname string, epType string, err error) {
+	raw, err := hex.DecodeString(Normalize(mac))
+	if err != nil || len(raw) != 6 {
+		return "", "", fmt.Errorf("'%s' is not a MAC address", mac)
+	}
+	if Normalize(prefix) != hex.EncodeToString(raw[:1]) {
+		return "", "", fmt.Errorf("MAC address '%s' does not have prefix '%s'", mac, prefix)
+	}
+
+	rack := int(raw[1])<<8 | int(raw[2])
+	chassis := int(raw[3])
+	slot := int(raw[4])
+	idx := int(raw[5])
+
+	switch {
+	case slot == 0 && idx == 0:
+		return fmt.Sprintf("x%dc%db0", rack, chassis), TypeChassisController, nil
+	case slot >= switchControllerOffset && idx == 0:
+		return fmt.Sprintf("x%dc%dr%db0", rack, chassis, slot-switchControllerOffset),
+			TypeSwitchController, nil
+	case slot >= nodeControllerOffset && slot < switchControllerOffset && idx&0x0F == 0:
+		return fmt.Sprintf("x%dc%ds%db%d", rack, chassis, slot-nodeControllerOffset, idx>>4),
+			TypeNodeController, nil
+	}
+	return "", "", fmt.Errorf("MAC address '%s' was not generated by MEDS", mac)
+}
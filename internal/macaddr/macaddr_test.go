@@ -0,0 +1,96 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package macaddr
+
+import "testing"
+
+func TestGenerateMAC(t *testing.T) {
+	ret := GenerateMAC("02", 7, 5, 3, 1)
+	ec := "02:00:07:05:03:10"
+	if ret != ec {
+		t.Errorf("Generated MAC did not match expectation:\nExpectation:\n%v\nGot:\n%v\n", ec, ret)
+	}
+}
+
+func TestGenerateMACnC(t *testing.T) {
+	ret := GenerateMACnC("02", 7, 5, 3, 1)
+	ec := "02:00:07:05:33:10"
+	if ret != ec {
+		t.Errorf("Generated nC MAC did not match expectation:\nExpectation:\n%v\nGot:\n%v\n", ec, ret)
+	}
+}
+
+func TestGenerateMACsC(t *testing.T) {
+	ret := GenerateMACsC("02", 7, 5, 3)
+	ec := "02:00:07:05:63:00"
+	if ret != ec {
+		t.Errorf("Generated sC MAC did not match expectation:\nExpectation:\n%v\nGot:\n%v\n", ec, ret)
+	}
+}
+
+func TestGenerateMACcC(t *testing.T) {
+	ret := GenerateMACcC("02", 7, 5)
+	ec := "02:00:07:05:00:00"
+	if ret != ec {
+		t.Errorf("Generated cC MAC did not match expectation:\nExpectation:\n%v\nGot:\n%v\n", ec, ret)
+	}
+}
+
+func TestParseMEDSMAC(t *testing.T) {
+	tests := []struct {
+		description string
+		prefix      string
+		mac         string
+		expectXname string
+		expectType  string
+		expectErr   bool
+	}{
+		{"Chassis controller", "02", GenerateMACcC("02", 1000, 3), "x1000c3b0", TypeChassisController, false},
+		{"Switch controller", "02", GenerateMACsC("02", 1000, 3, 7), "x1000c3r7b0", TypeSwitchController, false},
+		{"Node controller", "02", GenerateMACnC("02", 9000, 1, 7, 1), "x9000c1s7b1", TypeNodeController, false},
+		{"HSM formatted MAC", "02", "0203e8033000", "x1000c3s0b0", TypeNodeController, false},
+		{"Other prefix", "0e", GenerateMACcC("0E", 1001, 0), "x1001c0b0", TypeChassisController, false},
+		{"Wrong prefix", "02", GenerateMACcC("0E", 1001, 0), "", "", true},
+		{"Not generated by MEDS", "02", GenerateMAC("02", 1000, 3, 5, 0), "", "", true},
+		{"Bad node index", "02", "02:03:e8:03:30:01", "", "", true},
+		{"Not a MAC address", "02", "x1000c3s0b0", "", "", true},
+		{"Too short", "02", "02:03:e8:03:30", "", "", true},
+	}
+
+	for i, test := range tests {
+		xname, epType, err := ParseMEDSMAC(test.prefix, test.mac)
+		if test.expectErr {
+			if err == nil {
+				t.Errorf("Test %v (%s) Failed: Expected an error; Received %s %s", i, test.description, xname, epType)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %v (%s) Failed: Unexpected error: %v", i, test.description, err)
+		} else if xname != test.expectXname || epType != test.expectType {
+			t.Errorf("Test %v (%s) Failed: Expected %s %s; Received %s %s", i, test.description, test.expectXname, test.expectType, xname, epType)
+		}
+	}
+}
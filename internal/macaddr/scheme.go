@@ -0,0 +1,107 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package macaddr
+
+import (
+	"sort"
+	"sync"
+)
+
+// A MACScheme lays out the MAC addresses MEDS generates for the BMCs in a
+// cabinet.  Every scheme starts with the cabinet's prefix octet.
+type MACScheme interface {
+	// Name is how the scheme is selected in SLS and on the command line.
+	Name() string
+	ChassisController(prefix string, rack, chassis int) string
+	SwitchController(prefix string, rack, chassis, switchCard int) string
+	NodeController(prefix string, rack, chassis, slot, idx int) string
+	// Parse decodes a MAC address generated by the scheme, returning the
+	// xname of the BMC and its type (cC, sC or nC).
+	Parse(prefix, mac string) (xname string, epType string, err error)
+}
+
+// DefaultSchemeName is the scheme used for cabinets that don't ask for
+// another one.
+const DefaultSchemeName = "default"
+
+// DefaultScheme is the original MEDS layout; see GenerateMAC.
+var DefaultScheme MACScheme = defaultScheme{}
+
+var (
+	schemesLock sync.Mutex
+	schemes     = make(map[string]MACScheme)
+)
+
+func init() {
+	RegisterMACScheme(DefaultScheme)
+}
+
+// RegisterMACScheme makes a scheme available by name, replacing any
+// scheme already registered under that name.
+func RegisterMACScheme(scheme MACScheme) {
+	schemesLock.Lock()
+	defer schemesLock.Unlock()
+	schemes[scheme.Name()] = scheme
+}
+
+// GetMACScheme looks up a registered scheme by name.
+func GetMACScheme(name string) (MACScheme, bool) {
+	schemesLock.Lock()
+	defer schemesLock.Unlock()
+	scheme, ok := schemes[name]
+	return scheme, ok
+}
+
+// MACSchemeNames lists the registered schemes.
+func MACSchemeNames() []string {
+	schemesLock.Lock()
+	defer schemesLock.Unlock()
+	names := make([]string, 0, len(schemes))
+	for name := range schemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type defaultScheme struct{}
+
+func (defaultScheme) Name() string { return DefaultSchemeName }
+
+func (defaultScheme) ChassisController(prefix string, rack, chassis int) string {
+	return GenerateMACcC(prefix, rack, chassis)
+}
+
+func (defaultScheme) SwitchController(prefix string, rack, chassis, switchCard int) string {
+	return GenerateMACsC(prefix, rack, chassis, switchCard)
+}
+
+func (defaultScheme) NodeController(prefix string, rack, chassis, slot, idx int) string {
+	return GenerateMACnC(prefix, rack, chassis, slot, idx)
+}
+
+func (defaultScheme) Parse(prefix, mac string) (string, string, error) {
+	return ParseMEDSMAC(prefix, mac)
+}
@@ -0,0 +1,74 @@
+/*
+ * MIT License
+ *
+ * (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a
+ * copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ * THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ * OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ * ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ * OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package macaddr
+
+import "testing"
+
+func TestMACScheme_roundTrip(t *testing.T) {
+	for _, name := range MACSchemeNames() {
+		scheme, _ := GetMACScheme(name)
+		tests := []struct {
+			mac         string
+			expectXname string
+			expectType  string
+		}{
+			{scheme.ChassisController("02", 1000, 3), "x1000c3b0", TypeChassisController},
+			{scheme.SwitchController("02", 1000, 3, 7), "x1000c3r7b0", TypeSwitchController},
+			{scheme.NodeController("02", 9000, 1, 7, 1), "x9000c1s7b1", TypeNodeController},
+		}
+		seen := make(map[string]bool)
+		for i, test := range tests {
+			xname, epType, err := scheme.Parse("02", test.mac)
+			if err != nil || xname != test.expectXname || epType != test.expectType {
+				t.Errorf("Scheme %s test %v Failed: Expected %s %s from %s; Received %s %s (%v)",
+					name, i, test.expectXname, test.expectType, test.mac, xname, epType, err)
+			}
+			if seen[test.mac] {
+				t.Errorf("Scheme %s test %v Failed: %s generated twice", name, i, test.mac)
+			}
+			seen[test.mac] = true
+		}
+	}
+}
+
+// A scheme that differs from the default only in name.
+type renamedScheme struct {
+	MACScheme
+}
+
+func (renamedScheme) Name() string { return "renamed" }
+
+func TestRegisterMACScheme(t *testing.T) {
+	RegisterMACScheme(renamedScheme{DefaultScheme})
+
+	scheme, ok := GetMACScheme("renamed")
+	if !ok || scheme.Name() != "renamed" {
+		t.Fatalf("Registered scheme not found")
+	}
+	names := MACSchemeNames()
+	if len(names) != 2 || names[0] != DefaultSchemeName || names[1] != "renamed" {
+		t.Errorf("Expected [default renamed]; Received %v", names)
+	}
+}
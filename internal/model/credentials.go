@@ -27,8 +27,11 @@ package model
 import (
 	"fmt"
 	"path"
+	"sort"
+	"strings"
 
 	sstorage "github.com/Cray-HPE/hms-securestorage"
+	"github.com/Cray-HPE/hms-xname/xnametypes"
 )
 
 // CredentialsKeyPrefix is the base of the Vault key for credentials
@@ -42,6 +45,14 @@ const CredentialsGlobalKey = "global/ipmi"
 // Vault Key used for BMC SSH key info
 const CredentialsSSHKey = "bmc-ssh-creds"
 
+// Vault Key used for the BMC credentials of part of the system, such as a
+//   cabinet or chassis, rather than all of it
+const CredentialsBMCKey = "bmc-creds"
+
+// Vault Key used to keep the password a BMC was rotated to until the
+//   rotation has either completed or been rolled back
+const CredentialsRotationKey = "bmc-rotation-creds"
+
 // A MedsCredStore holds the connection to a Vault and the base path
 //   used to formulate keys
 type MedsCredStore struct {
@@ -92,16 +103,174 @@ func (mcs *MedsCredStore) StoreGlobalCredentials(medsCred MedsCredentials) (err
 	return
 }
 
+// Delete the global credentials for Mountain blade BMCs from Vault.
+func (mcs *MedsCredStore) DeleteGlobalCredentials() (err error) {
+	err = mcs.SS.Delete(path.Join(mcs.CCPath, CredentialsGlobalKey))
+	return
+}
+
+// Fetch the BMC credentials for an XName and everything under it, such as
+// a cabinet or chassis.
+func (mcs *MedsCredStore) FindBMCCredentials(xname string) (medsCred MedsCredentials, err error) {
+	err = mcs.SS.Lookup(path.Join(mcs.CCPath, CredentialsBMCKey, xname), &medsCred)
+	return
+}
+
+// Store the BMC credentials for an XName and everything under it.
+func (mcs *MedsCredStore) StoreBMCCredentials(xname string, medsCred MedsCredentials) (err error) {
+	err = mcs.SS.Store(path.Join(mcs.CCPath, CredentialsBMCKey, xname), medsCred)
+	return
+}
+
+// Delete the BMC credentials for an XName and everything under it.
+func (mcs *MedsCredStore) DeleteBMCCredentials(xname string) (err error) {
+	err = mcs.SS.Delete(path.Join(mcs.CCPath, CredentialsBMCKey, xname))
+	return
+}
+
+// Fetch the credentials a BMC was being rotated to when the rotation failed.
+func (mcs *MedsCredStore) FindRotationCredentials(xname string) (medsCred MedsCredentials, err error) {
+	err = mcs.SS.Lookup(path.Join(mcs.CCPath, CredentialsRotationKey, xname), &medsCred)
+	return
+}
+
+// Store the credentials a BMC is being rotated to, so they aren't lost if
+// they can't be stored as the BMC's own or rolled back.
+func (mcs *MedsCredStore) StoreRotationCredentials(xname string, medsCred MedsCredentials) (err error) {
+	err = mcs.SS.Store(path.Join(mcs.CCPath, CredentialsRotationKey, xname), medsCred)
+	return
+}
+
+// Delete the credentials a BMC was being rotated to.
+func (mcs *MedsCredStore) DeleteRotationCredentials(xname string) (err error) {
+	err = mcs.SS.Delete(path.Join(mcs.CCPath, CredentialsRotationKey, xname))
+	return
+}
+
+// Resolve the credentials for a Mountain blade BMC by walking up its XName
+// hierarchy: the first of the BMC's own, its parents' up to its cabinet
+// (for x1000c3s5b0: x1000c3s5b0, x1000c3s5, x1000c3 then x1000) and the
+// global credentials that has any is used.  'source' is the Vault key they
+// came from, or empty if there are none anywhere.  MEDS only resolves
+// credentials for BMCs that don't yet have any of their own in hms-creds.
+func (mcs *MedsCredStore) ResolveBMCCredentials(xname string) (medsCred MedsCredentials, source string, err error) {
+	keys := make([]string, 0)
+	for _, x := range xnameHierarchy(xname) {
+		keys = append(keys, path.Join(mcs.CCPath, CredentialsBMCKey, x))
+	}
+	keys = append(keys, path.Join(mcs.CCPath, CredentialsGlobalKey))
+
+	for _, key := range keys {
+		medsCred = MedsCredentials{}
+		err = mcs.SS.Lookup(key, &medsCred)
+		if err != nil || medsCred.Username != "" {
+			// Don't fall back to less specific creds if Vault fails.
+			source = key
+			return
+		}
+	}
+	return
+}
+
 /////////////////////////////// BMC SSH CREDS ////////////////////////////
 
+// Like MedsCredentials, keep the password out of any output.  The
+// authorized key is a public key, so it is shown.
+func (sshCreds MedsSSHCredentials) String() string {
+	return fmt.Sprintf("Username: %s, Password: <REDACTED>, AuthorizedKey: %s",
+		sshCreds.Username, sshCreds.AuthorizedKey)
+}
+
 // Fetch BMC SSH creds.  These can be all the same/global, or can be indexed by
 // XName.  If the 'xname' parameter is empty, we'll assume global.
 
 func (mcs *MedsCredStore) FindBMCSSHCredentials(xname string) (sshCreds MedsSSHCredentials, err error) {
-	if xname == "" {
-		err = mcs.SS.Lookup(path.Join(mcs.CCPath, CredentialsKeyPrefix), &sshCreds)
-	} else {
-		err = mcs.SS.Lookup(path.Join(mcs.CCPath, CredentialsKeyPrefix, CredentialsSSHKey, xname), &sshCreds)
+	err = mcs.SS.Lookup(mcs.bmcSSHCredentialsKey(xname), &sshCreds)
+	return
+}
+
+// Store BMC SSH creds, globally if 'xname' is empty.
+func (mcs *MedsCredStore) StoreBMCSSHCredentials(xname string, sshCreds MedsSSHCredentials) (err error) {
+	err = mcs.SS.Store(mcs.bmcSSHCredentialsKey(xname), sshCreds)
+	return
+}
+
+// Delete BMC SSH creds, globally if 'xname' is empty.  Deleting the global
+// creds leaves any per-XName ones alone.
+func (mcs *MedsCredStore) DeleteBMCSSHCredentials(xname string) (err error) {
+	err = mcs.SS.Delete(mcs.bmcSSHCredentialsKey(xname))
+	return
+}
+
+// List the keys directly under 'keyPath', or none if there is nothing there.
+// Vault answers a LIST of a missing path with nothing at all, which the
+// Vault adapter's LookupKeys doesn't check for and panics on.
+func LookupKeys(ss sstorage.SecureStorage, keyPath string) (keys []string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			keys, err = []string{}, nil
+		}
+	}()
+	keys, err = ss.LookupKeys(keyPath)
+	return
+}
+
+// List the XNames that have their own BMC SSH creds, in order.
+func (mcs *MedsCredStore) ListBMCSSHCredentialXnames() (xnames []string, err error) {
+	keys, err := LookupKeys(mcs.SS, path.Join(mcs.CCPath, CredentialsKeyPrefix, CredentialsSSHKey))
+	if err != nil {
+		return
+	}
+	xnames = make([]string, 0, len(keys))
+	for _, key := range keys {
+		// Vault lists sub-paths with a trailing slash; they aren't creds.
+		if !strings.HasSuffix(key, "/") {
+			xnames = append(xnames, key)
+		}
+	}
+	sort.Strings(xnames)
+	return
+}
+
+// Resolve the BMC SSH creds for an XName the same way as
+// ResolveBMCCredentials: the first of its own, its parents' up to its
+// cabinet and the global ones that has any is used.
+func (mcs *MedsCredStore) ResolveBMCSSHCredentials(xname string) (sshCreds MedsSSHCredentials, source string, err error) {
+	keys := make([]string, 0)
+	for _, x := range xnameHierarchy(xname) {
+		keys = append(keys, mcs.bmcSSHCredentialsKey(x))
+	}
+	keys = append(keys, mcs.bmcSSHCredentialsKey(""))
+
+	for _, key := range keys {
+		sshCreds = MedsSSHCredentials{}
+		err = mcs.SS.Lookup(key, &sshCreds)
+		if err != nil || sshCreds.Username != "" {
+			source = key
+			return
+		}
 	}
 	return
 }
+
+// The Vault key of the BMC SSH creds for 'xname', or the global ones if it
+// is empty.
+func (mcs *MedsCredStore) bmcSSHCredentialsKey(xname string) string {
+	if xname == "" {
+		return path.Join(mcs.CCPath, CredentialsKeyPrefix)
+	}
+	return path.Join(mcs.CCPath, CredentialsKeyPrefix, CredentialsSSHKey, xname)
+}
+
+// List an XName and its parents up to its cabinet, most specific first.
+func xnameHierarchy(xname string) []string {
+	hierarchy := make([]string, 0)
+	for x := xnametypes.NormalizeHMSCompID(xname); x != ""; x = xnametypes.GetHMSCompParent(x) {
+		hmsType := xnametypes.GetHMSType(x)
+		if hmsType == xnametypes.System || hmsType == xnametypes.HMSTypeInvalid {
+			break
+		}
+		hierarchy = append(hierarchy, x)
+	}
+	return hierarchy
+}
@@ -25,7 +25,9 @@
 package model
 
 import (
+	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	mtest "github.com/Cray-HPE/hms-meds/internal/testing"
@@ -96,3 +98,273 @@ func TestMedsCredentials_String(t *testing.T) {
 		})
 	}
 }
+
+func TestMedsCredStore_BMCSSHCredentials(t *testing.T) {
+	ss := mtest.NewKvMock()
+	credStorage := NewMedsCredStore(CredentialsKeyPrefix, ss)
+
+	globalCreds := MedsSSHCredentials{Username: "root", Password: "initial0", AuthorizedKey: "ssh-rsa AAAA global"}
+	xnameCreds := MedsSSHCredentials{Username: "root", Password: "terminal0", AuthorizedKey: "ssh-rsa AAAA x1000c0s0b0"}
+
+	tests := []struct {
+		name    string
+		xname   string
+		creds   MedsSSHCredentials
+		wantKey string
+	}{{
+		name:    "Global",
+		xname:   "",
+		creds:   globalCreds,
+		wantKey: CredentialsKeyPrefix + "/" + CredentialsKeyPrefix,
+	}, {
+		name:    "PerXname",
+		xname:   "x1000c0s0b0",
+		creds:   xnameCreds,
+		wantKey: CredentialsKeyPrefix + "/" + CredentialsKeyPrefix + "/" + CredentialsSSHKey + "/x1000c0s0b0",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := credStorage.StoreBMCSSHCredentials(tt.xname, tt.creds); err != nil {
+				t.Errorf("MedsCredStore.StoreBMCSSHCredentials() err: %s", err)
+			}
+
+			var stored MedsSSHCredentials
+			ss.Lookup(tt.wantKey, &stored)
+			if !reflect.DeepEqual(stored, tt.creds) {
+				t.Errorf("Vault key %s = %v, want %v", tt.wantKey, stored, tt.creds)
+			}
+
+			gotCreds, err := credStorage.FindBMCSSHCredentials(tt.xname)
+			if err != nil || !reflect.DeepEqual(gotCreds, tt.creds) {
+				t.Errorf("MedsCredStore.FindBMCSSHCredentials() = %v (err: %v), want %v", gotCreds, err, tt.creds)
+			}
+		})
+	}
+
+	// Deleting the global creds leaves the per-xname ones alone.
+	if err := credStorage.DeleteBMCSSHCredentials(""); err != nil {
+		t.Errorf("MedsCredStore.DeleteBMCSSHCredentials() err: %s", err)
+	}
+	if gotCreds, _ := credStorage.FindBMCSSHCredentials(""); !reflect.DeepEqual(gotCreds, MedsSSHCredentials{}) {
+		t.Errorf("MedsCredStore.FindBMCSSHCredentials() after delete = %v, want empty", gotCreds)
+	}
+	if gotCreds, _ := credStorage.FindBMCSSHCredentials("x1000c0s0b0"); !reflect.DeepEqual(gotCreds, xnameCreds) {
+		t.Errorf("MedsCredStore.FindBMCSSHCredentials() = %v, want %v", gotCreds, xnameCreds)
+	}
+}
+
+func TestMedsCredStore_DeleteGlobalCredentials(t *testing.T) {
+	ss := mtest.NewKvMock()
+	credStorage := NewMedsCredStore(CredentialsKeyPrefix, ss)
+
+	credStorage.StoreGlobalCredentials(MedsCredentials{Username: "foo", Password: "bar"})
+	if err := credStorage.DeleteGlobalCredentials(); err != nil {
+		t.Errorf("MedsCredStore.DeleteGlobalCredentials() err: %s", err)
+	}
+	if gotMedsCred, _ := credStorage.FindGlobalCredentials(); !reflect.DeepEqual(gotMedsCred, MedsCredentials{}) {
+		t.Errorf("MedsCredStore.FindGlobalCredentials() after delete = %v, want empty", gotMedsCred)
+	}
+}
+
+func TestMedsCredStore_RotationCredentials(t *testing.T) {
+	ss := mtest.NewKvMock()
+	credStorage := NewMedsCredStore(CredentialsKeyPrefix, ss)
+
+	medsCred := MedsCredentials{Username: "root", Password: "n3wsecret"}
+	if err := credStorage.StoreRotationCredentials("x1000c0s0b0", medsCred); err != nil {
+		t.Errorf("MedsCredStore.StoreRotationCredentials() err: %s", err)
+	}
+	if gotMedsCred, _ := credStorage.FindRotationCredentials("x1000c0s0b0"); !reflect.DeepEqual(gotMedsCred, medsCred) {
+		t.Errorf("MedsCredStore.FindRotationCredentials() = %v, want %v", gotMedsCred, medsCred)
+	}
+	if gotMedsCred, _ := credStorage.FindBMCCredentials("x1000c0s0b0"); !reflect.DeepEqual(gotMedsCred, MedsCredentials{}) {
+		t.Errorf("MedsCredStore.FindBMCCredentials() = %v, want empty", gotMedsCred)
+	}
+
+	if err := credStorage.DeleteRotationCredentials("x1000c0s0b0"); err != nil {
+		t.Errorf("MedsCredStore.DeleteRotationCredentials() err: %s", err)
+	}
+	if gotMedsCred, _ := credStorage.FindRotationCredentials("x1000c0s0b0"); !reflect.DeepEqual(gotMedsCred, MedsCredentials{}) {
+		t.Errorf("MedsCredStore.FindRotationCredentials() after delete = %v, want empty", gotMedsCred)
+	}
+}
+
+func TestMedsCredStore_ListBMCSSHCredentialXnames(t *testing.T) {
+	ss := mtest.NewKvMock()
+	credStorage := NewMedsCredStore(CredentialsKeyPrefix, ss)
+
+	xnames, err := credStorage.ListBMCSSHCredentialXnames()
+	if err != nil || len(xnames) != 0 {
+		t.Errorf("MedsCredStore.ListBMCSSHCredentialXnames() = %v (err: %v), want none", xnames, err)
+	}
+
+	sshCreds := MedsSSHCredentials{Username: "root", Password: "initial0"}
+	credStorage.StoreBMCSSHCredentials("", sshCreds)
+	credStorage.StoreBMCSSHCredentials("x1000c0s1b0", sshCreds)
+	credStorage.StoreBMCSSHCredentials("x1000c0s0b0", sshCreds)
+	credStorage.StoreGlobalCredentials(MedsCredentials{Username: "root", Password: "initial0"})
+	ss.Store(CredentialsKeyPrefix+"/"+CredentialsKeyPrefix+"/"+CredentialsSSHKey+"/old/x1000c0s2b0", sshCreds)
+
+	want := []string{"x1000c0s0b0", "x1000c0s1b0"}
+	xnames, err = credStorage.ListBMCSSHCredentialXnames()
+	if err != nil || !reflect.DeepEqual(xnames, want) {
+		t.Errorf("MedsCredStore.ListBMCSSHCredentialXnames() = %v (err: %v), want %v", xnames, err, want)
+	}
+
+	credStorage.DeleteBMCSSHCredentials("x1000c0s1b0")
+	want = []string{"x1000c0s0b0"}
+	xnames, err = credStorage.ListBMCSSHCredentialXnames()
+	if err != nil || !reflect.DeepEqual(xnames, want) {
+		t.Errorf("MedsCredStore.ListBMCSSHCredentialXnames() after delete = %v (err: %v), want %v", xnames, err, want)
+	}
+
+	// Once the last one is gone Vault no longer has the path at all.
+	credStorage.DeleteBMCSSHCredentials("x1000c0s0b0")
+	ss.Delete(CredentialsKeyPrefix + "/" + CredentialsKeyPrefix + "/" + CredentialsSSHKey + "/old/x1000c0s2b0")
+	xnames, err = credStorage.ListBMCSSHCredentialXnames()
+	if err != nil || len(xnames) != 0 {
+		t.Errorf("MedsCredStore.ListBMCSSHCredentialXnames() after deleting all = %v (err: %v), want none", xnames, err)
+	}
+}
+
+func TestMedsSSHCredentials_String(t *testing.T) {
+	tests := []struct {
+		name     string
+		sshCreds MedsSSHCredentials
+		want     string
+	}{{
+		name:     "RedactedOutput",
+		sshCreds: MedsSSHCredentials{Username: "root", Password: "terminal0", AuthorizedKey: "ssh-ed25519 AAAA"},
+		want:     "Username: root, Password: <REDACTED>, AuthorizedKey: ssh-ed25519 AAAA",
+	}, {
+		name:     "NoKey",
+		sshCreds: MedsSSHCredentials{Username: "root", Password: "terminal0"},
+		want:     "Username: root, Password: <REDACTED>, AuthorizedKey: ",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sshCreds.String(); got != tt.want {
+				t.Errorf("MedsSSHCredentials.String() = %v, want %v", got, tt.want)
+			}
+			if got := fmt.Sprintf("%v", tt.sshCreds); strings.Contains(got, "terminal0") {
+				t.Errorf("fmt.Sprintf(\"%%v\") = %v, want the password redacted", got)
+			}
+		})
+	}
+}
+
+func TestMedsCredStore_ResolveBMCCredentials(t *testing.T) {
+	ss := mtest.NewKvMock()
+	credStorage := NewMedsCredStore(CredentialsKeyPrefix, ss)
+
+	globalCreds := MedsCredentials{Username: "root", Password: "global"}
+	cabinetCreds := MedsCredentials{Username: "root", Password: "cabinet"}
+	chassisCreds := MedsCredentials{Username: "root", Password: "chassis"}
+	bmcCreds := MedsCredentials{Username: "admin", Password: "bmc"}
+
+	tests := []struct {
+		name       string
+		xname      string
+		wantCreds  MedsCredentials
+		wantSource string
+	}{{
+		name:       "Nothing",
+		xname:      "x1000c3s5b0",
+		wantCreds:  MedsCredentials{},
+		wantSource: "",
+	}, {
+		name:       "Global",
+		xname:      "x1000c3s5b0",
+		wantCreds:  globalCreds,
+		wantSource: CredentialsKeyPrefix + "/" + CredentialsGlobalKey,
+	}, {
+		name:       "Cabinet",
+		xname:      "x1000c3s5b0",
+		wantCreds:  cabinetCreds,
+		wantSource: CredentialsKeyPrefix + "/" + CredentialsBMCKey + "/x1000",
+	}, {
+		name:       "Chassis",
+		xname:      "x1000c3s5b0",
+		wantCreds:  chassisCreds,
+		wantSource: CredentialsKeyPrefix + "/" + CredentialsBMCKey + "/x1000c3",
+	}, {
+		name:       "OtherChassis",
+		xname:      "x1000c2s5b0",
+		wantCreds:  cabinetCreds,
+		wantSource: CredentialsKeyPrefix + "/" + CredentialsBMCKey + "/x1000",
+	}, {
+		name:       "BMC",
+		xname:      "x1000c3s5b0",
+		wantCreds:  bmcCreds,
+		wantSource: CredentialsKeyPrefix + "/" + CredentialsBMCKey + "/x1000c3s5b0",
+	}, {
+		name:       "OtherCabinet",
+		xname:      "x1001c3s5b0",
+		wantCreds:  globalCreds,
+		wantSource: CredentialsKeyPrefix + "/" + CredentialsGlobalKey,
+	}}
+
+	// Each test adds the next, more specific, level.
+	setup := map[string]func(){
+		"Global":  func() { credStorage.StoreGlobalCredentials(globalCreds) },
+		"Cabinet": func() { credStorage.StoreBMCCredentials("x1000", cabinetCreds) },
+		"Chassis": func() { credStorage.StoreBMCCredentials("x1000c3", chassisCreds) },
+		"BMC":     func() { credStorage.StoreBMCCredentials("x1000c3s5b0", bmcCreds) },
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if f, ok := setup[tt.name]; ok {
+				f()
+			}
+			gotCreds, gotSource, err := credStorage.ResolveBMCCredentials(tt.xname)
+			if err != nil || !reflect.DeepEqual(gotCreds, tt.wantCreds) || gotSource != tt.wantSource {
+				t.Errorf("MedsCredStore.ResolveBMCCredentials(%s) = %v, %s (err: %v), want %v, %s",
+					tt.xname, gotCreds, gotSource, err, tt.wantCreds, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestMedsCredStore_ResolveBMCSSHCredentials(t *testing.T) {
+	ss := mtest.NewKvMock()
+	credStorage := NewMedsCredStore(CredentialsKeyPrefix, ss)
+
+	globalCreds := MedsSSHCredentials{Username: "root", AuthorizedKey: "ssh-ed25519 global"}
+	chassisCreds := MedsSSHCredentials{Username: "root", AuthorizedKey: "ssh-ed25519 chassis"}
+	credStorage.StoreBMCSSHCredentials("", globalCreds)
+	credStorage.StoreBMCSSHCredentials("x1000c3", chassisCreds)
+
+	sshPrefix := CredentialsKeyPrefix + "/" + CredentialsKeyPrefix
+	tests := []struct {
+		name       string
+		xname      string
+		wantCreds  MedsSSHCredentials
+		wantSource string
+	}{{
+		name:       "Chassis",
+		xname:      "x1000c3s5b0",
+		wantCreds:  chassisCreds,
+		wantSource: sshPrefix + "/" + CredentialsSSHKey + "/x1000c3",
+	}, {
+		name:       "ChassisItself",
+		xname:      "x1000c3b0",
+		wantCreds:  chassisCreds,
+		wantSource: sshPrefix + "/" + CredentialsSSHKey + "/x1000c3",
+	}, {
+		name:       "Global",
+		xname:      "x1000c2s5b0",
+		wantCreds:  globalCreds,
+		wantSource: sshPrefix,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotCreds, gotSource, err := credStorage.ResolveBMCSSHCredentials(tt.xname)
+			if err != nil || !reflect.DeepEqual(gotCreds, tt.wantCreds) || gotSource != tt.wantSource {
+				t.Errorf("MedsCredStore.ResolveBMCSSHCredentials(%s) = %v, %s (err: %v), want %v, %s",
+					tt.xname, gotCreds, gotSource, err, tt.wantCreds, tt.wantSource)
+			}
+		})
+	}
+}
@@ -26,6 +26,8 @@ package testing
 
 import (
 	"errors"
+	"sort"
+	"strings"
 
 	"github.com/mitchellh/mapstructure"
 )
@@ -53,7 +55,37 @@ func (kv KvMock) Delete(key string) error {
 	delete(kv.storage, key)
 	return nil
 }
+
+// vaultList is what the Vault client returns for a LIST.
+type vaultList struct {
+	Data map[string]interface{}
+}
+
+// LookupKeys lists the keys directly under keyPath, like Vault does: keys
+// further down are listed as the next path element with a trailing slash.
+// If there is nothing under keyPath it panics, as the Vault adapter does
+// when Vault returns nothing for a missing path.
 func (kv KvMock) LookupKeys(keyPath string) (keys []string, err error) {
+	prefix := strings.TrimSuffix(keyPath, "/") + "/"
+	seen := make(map[string]bool)
+	for key := range kv.storage {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(key, prefix)
+		if i := strings.Index(name, "/"); i >= 0 {
+			name = name[:i+1]
+		}
+		if !seen[name] {
+			seen[name] = true
+			keys = append(keys, name)
+		}
+	}
+	if len(keys) == 0 {
+		var missing *vaultList
+		_ = missing.Data["keys"]
+	}
+	sort.Strings(keys)
 	return
 }
 